@@ -0,0 +1,164 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	// Model
+	"aws-price-scanner/model"
+	// Process
+	"aws-price-scanner/process"
+)
+
+const (
+	INDEX_FILENAME = "index.json"
+	PAGE_SIZE      = 100
+)
+
+// Source reads products from AWS Bulk Price List offer files on local disk
+type Source struct {
+	Path string
+}
+
+// Offer index ("index.json" of AWS Bulk Price List)
+type offerIndex struct {
+	Offers map[string]struct {
+		OfferCode         string `json:"offerCode"`
+		CurrentVersionUrl string `json:"currentVersionUrl"`
+	} `json:"offers"`
+}
+
+/*
+ * New source to read offer files
+ * @param			path {string} directory with index.json and offer files, or an offer file (*.json, *.csv)
+ * @response	{*Source} source object
+ * @response	{error} error object (contain nil)
+ */
+func NewSource(path string) (*Source, error) {
+	if path == "" {
+		return nil, errors.New("Setting the offer file path is mandatory")
+	} else if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return &Source{Path: path}, nil
+}
+
+/*
+ * [Method] Read products of service from offer file (sorted by sku, filtered by the filters of service definition)
+ * @param			ctx {context.Context} context
+ * @param			def {process.ServiceDefinition} service definition
+ * @param			emit {func([]model.RawData) error} callback for a page of products
+ * @response	{error} error object (contain nil)
+ */
+func (s *Source) Products(ctx context.Context, def process.ServiceDefinition, emit func(page []model.RawData) error) error {
+	// Find offer file
+	filename, err := s.findOfferFile(def.PricingServiceCode)
+	if err != nil {
+		return err
+	}
+	// Split filters (term type is known after terms are read)
+	productFilters := make([]model.Filter, 0, len(def.Filters))
+	termFilters := make([]model.Filter, 0)
+	for _, filter := range def.Filters {
+		if strings.EqualFold(filter.Field, "termType") {
+			termFilters = append(termFilters, filter)
+		} else {
+			productFilters = append(productFilters, filter)
+		}
+	}
+	// Read offer file
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var products map[string]*model.RawData
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		products, err = readCSV(ctx, file, productFilters)
+	} else {
+		products, err = readJSON(ctx, file, productFilters)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	// Sort by sku
+	skus := make([]string, 0, len(products))
+	for sku, rawData := range products {
+		if matchFilters(termFilters, *rawData) {
+			skus = append(skus, sku)
+		}
+	}
+	sort.Strings(skus)
+	// Emit pages
+	for start := 0; start < len(skus); start += PAGE_SIZE {
+		end := start + PAGE_SIZE
+		if end > len(skus) {
+			end = len(skus)
+		}
+		page := make([]model.RawData, 0, end-start)
+		for _, sku := range skus[start:end] {
+			page = append(page, *products[sku])
+		}
+		if err := emit(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Source) findOfferFile(serviceCode string) (string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", err
+	} else if !info.IsDir() {
+		return s.Path, nil
+	}
+
+	// Candidates (from offer index, then conventional names)
+	candidates := make([]string, 0)
+	if content, err := ioutil.ReadFile(filepath.Join(s.Path, INDEX_FILENAME)); err == nil {
+		var index offerIndex
+		if err := json.Unmarshal(content, &index); err != nil {
+			return "", fmt.Errorf("%s: %w", INDEX_FILENAME, err)
+		}
+		if offer, ok := index.Offers[serviceCode]; ok && offer.CurrentVersionUrl != "" {
+			url := filepath.FromSlash(strings.TrimPrefix(offer.CurrentVersionUrl, "/"))
+			trimmed := filepath.FromSlash(strings.TrimPrefix(offer.CurrentVersionUrl, "/offers/v1.0/aws/"))
+			for _, name := range []string{url, trimmed} {
+				candidates = append(candidates, name, strings.TrimSuffix(name, filepath.Ext(name))+".csv")
+			}
+		}
+	}
+	candidates = append(candidates,
+		serviceCode+".json",
+		serviceCode+".csv",
+		filepath.Join(serviceCode, "current", "index.json"),
+		filepath.Join(serviceCode, "current", "index.csv"),
+		filepath.Join(serviceCode, "index.json"),
+		filepath.Join(serviceCode, "index.csv"),
+	)
+	for _, candidate := range candidates {
+		filename := filepath.Join(s.Path, candidate)
+		if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+			return filename, nil
+		}
+	}
+	return "", fmt.Errorf("Not found offer file for %s in %s", serviceCode, s.Path)
+}
+
+func matchFilters(filters []model.Filter, rawData model.RawData) bool {
+	for _, filter := range filters {
+		if !filter.Match(rawData) {
+			return false
+		}
+	}
+	return true
+}
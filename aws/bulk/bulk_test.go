@@ -0,0 +1,237 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	// AWS
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsPricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+
+	// Pricing API
+	"aws-price-scanner/aws/pricing"
+	// Model
+	"aws-price-scanner/model"
+	// Process
+	"aws-price-scanner/process"
+	// Output sink
+	"aws-price-scanner/sink"
+)
+
+// Sink that keeps the body of the last artifact
+type memorySink struct {
+	body []byte
+}
+
+func (ms *memorySink) Write(ctx context.Context, artifact sink.Artifact) (string, error) {
+	ms.body = artifact.Body
+	return artifact.Name, nil
+}
+
+// Client of AWS pricing API that serves the products of an offer file (JSON) matching the filters, a page per product
+type offerProductsClient struct {
+	priceList []string
+}
+
+func newOfferProductsClient(t *testing.T, filename string) *offerProductsClient {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offer struct {
+		Version  string                                       `json:"version"`
+		Products map[string]json.RawMessage                   `json:"products"`
+		Terms    map[string]map[string]map[string]interface{} `json:"terms"`
+	}
+	if err := json.Unmarshal(content, &offer); err != nil {
+		t.Fatal(err)
+	}
+	client := &offerProductsClient{}
+	for sku, product := range offer.Products {
+		terms := make(map[string]interface{})
+		for termType, skus := range offer.Terms {
+			if term, ok := skus[sku]; ok {
+				terms[termType] = term
+			}
+		}
+		data, err := json.Marshal(map[string]interface{}{"product": product, "terms": terms, "version": offer.Version})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.priceList = append(client.priceList, string(data))
+	}
+	return client
+}
+
+func (oc *offerProductsClient) GetProducts(ctx context.Context, params *awsPricing.GetProductsInput, optFns ...func(*awsPricing.Options)) (*awsPricing.GetProductsOutput, error) {
+	// Find the next matching product after the token (index of product)
+	start := 0
+	if params.NextToken != nil {
+		var err error
+		if start, err = strconv.Atoi(*params.NextToken); err != nil {
+			return nil, err
+		}
+	}
+	for i := start; i < len(oc.priceList); i++ {
+		var rawData model.RawData
+		if err := json.Unmarshal([]byte(oc.priceList[i]), &rawData); err != nil {
+			return nil, err
+		}
+		matched := true
+		for _, filter := range params.Filters {
+			matched = matched && (model.Filter{Field: aws.ToString(filter.Field), Value: aws.ToString(filter.Value)}).Match(rawData)
+		}
+		if matched {
+			output := &awsPricing.GetProductsOutput{PriceList: []string{oc.priceList[i]}}
+			if i+1 < len(oc.priceList) {
+				output.NextToken = aws.String(strconv.Itoa(i + 1))
+			}
+			return output, nil
+		}
+	}
+	return &awsPricing.GetProductsOutput{}, nil
+}
+
+func scanCatalog(t *testing.T, source process.Source, def process.ServiceDefinition) (*model.ScanResult, string) {
+	output := &memorySink{}
+	result, err := process.OperatePriceCommand(context.Background(), source, def, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, string(output.body)
+}
+
+func TestSourcesProduceSameCatalog(t *testing.T) {
+	def, ok := process.Lookup(model.AWS_SERVICE_CODE_RDS)
+	if !ok {
+		t.Fatal("built-in RDS definition is not registered")
+	}
+	jsonResult, jsonCatalog := scanCatalog(t, &Source{Path: filepath.Join("testdata", "AmazonRDS.json")}, def)
+	csvResult, csvCatalog := scanCatalog(t, &Source{Path: filepath.Join("testdata", "AmazonRDS.csv")}, def)
+	apiResult, apiCatalog := scanCatalog(t, pricing.Source{Client: newOfferProductsClient(t, filepath.Join("testdata", "AmazonRDS.json"))}, def)
+
+	// The product of AWS Outposts is filtered out
+	for _, result := range []*model.ScanResult{jsonResult, csvResult, apiResult} {
+		if result.Products != 4 || result.Merged != 4 || result.Collisions != 0 {
+			t.Errorf("products %d, merged %d, collisions %d, want 4, 4 and 0", result.Products, result.Merged, result.Collisions)
+		}
+	}
+	if csvCatalog != jsonCatalog {
+		t.Errorf("catalog of CSV differs from JSON\n got: %s\nwant: %s", csvCatalog, jsonCatalog)
+	}
+	if apiCatalog != jsonCatalog {
+		t.Errorf("catalog of pricing API differs from JSON\n got: %s\nwant: %s", apiCatalog, jsonCatalog)
+	}
+
+	// Check the content of catalog
+	var catalog model.Catalog
+	if err := json.Unmarshal([]byte(jsonCatalog), &catalog); err != nil {
+		t.Fatal(err)
+	}
+	instance := catalog.Regions["us-east-1"]["instance"]["db.m5.large"]
+	if instance == nil {
+		t.Fatalf("no db.m5.large instance in catalog: %s", jsonCatalog)
+	}
+	if len(instance.OnDemand["CreateDBInstance:0002"]) != 1 || len(instance.OnDemand["CreateDBInstance:0002:Multi-AZ"]) != 1 {
+		t.Errorf("onDemand of instance %v, want Single-AZ and Multi-AZ prices", instance.OnDemand)
+	}
+	if reserved := instance.Reserved["CreateDBInstance:0002"]; len(reserved) != 1 || len(reserved[0].Upfront) != 1 || reserved[0].Upfront[0].Amount != "1000" {
+		t.Errorf("reserved of instance %+v, want upfront 1000", reserved)
+	}
+	backup := catalog.Regions["eu-west-1"]["backup"]["rds"]
+	if backup == nil || len(backup.OnDemand["storage"]) != 2 || backup.OnDemand["storage"][1].Range.Begin != "10240" {
+		t.Errorf("backup %+v, want 2 tiers", backup)
+	}
+}
+
+func TestAttributeName(t *testing.T) {
+	tests := map[string]string{
+		"Instance Type":               "instanceType",
+		"Location Type":               "locationType",
+		"Region Code":                 "regionCode",
+		"Location":                    "location",
+		"Tenancy":                     "tenancy",
+		"volumeApiName":               "volumeApiName",
+		"Pre Installed S/W":           "preInstalledSw",
+		"Physical Processor":          "physicalProcessor",
+		"Dedicated EBS Throughput":    "dedicatedEbsThroughput",
+		"Storage Media":               "storageMedia",
+		"vCPU":                        "vcpu",
+		"usageType":                   "usagetype",
+		"serviceCode":                 "servicecode",
+		"Instance Capacity":           "instancecapacity",
+		"Max IOPS/volume":             "maxIopsvolume",
+		"Max throughput/volume":       "maxThroughputvolume",
+		"Max IOPS Burst Performance":  "maxIopsBurstPerformance",
+		"PreInstalled S/W":            "preInstalledSw",
+		"From Location Type":          "fromLocationType",
+		"Elastic Graphics Type (GPU)": "elasticGraphicsTypeGpu",
+	}
+	for column, expected := range tests {
+		if name := attributeName(column); name != expected {
+			t.Errorf("attributeName(%q) = %q, want %q", column, name, expected)
+		}
+	}
+}
+
+func TestFindOfferFile(t *testing.T) {
+	write := func(t *testing.T, dir string, name string, content string) {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := `{"offers": {"AmazonRDS": {"offerCode": "AmazonRDS", "currentVersionUrl": "/offers/v1.0/aws/AmazonRDS/current/index.json"}}}`
+
+	tests := []struct {
+		name     string
+		files    []string
+		index    string
+		path     string
+		expected string
+	}{
+		{"offer file", []string{"rds.csv"}, "", "rds.csv", "rds.csv"},
+		{"index url", []string{"offers/v1.0/aws/AmazonRDS/current/index.json", "AmazonRDS.json"}, index, "", "offers/v1.0/aws/AmazonRDS/current/index.json"},
+		{"index url of CSV", []string{"offers/v1.0/aws/AmazonRDS/current/index.csv"}, index, "", "offers/v1.0/aws/AmazonRDS/current/index.csv"},
+		{"index url without prefix", []string{"AmazonRDS/current/index.json"}, index, "", "AmazonRDS/current/index.json"},
+		{"service code JSON", []string{"AmazonRDS.json", "AmazonRDS.csv"}, "", "", "AmazonRDS.json"},
+		{"service code CSV", []string{"AmazonRDS.csv", "AmazonRDS/index.json"}, "", "", "AmazonRDS.csv"},
+		{"current version", []string{"AmazonRDS/current/index.csv", "AmazonRDS/index.json"}, "", "", "AmazonRDS/current/index.csv"},
+		{"service directory", []string{"AmazonRDS/index.json"}, "", "", "AmazonRDS/index.json"},
+		{"other service only", []string{"AmazonEC2.json"}, index, "", ""},
+		{"invalid index", []string{"AmazonRDS.json"}, "{", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range test.files {
+				write(t, dir, name, "{}")
+			}
+			if test.index != "" {
+				write(t, dir, INDEX_FILENAME, test.index)
+			}
+			source := &Source{Path: filepath.Join(dir, test.path)}
+			filename, err := source.findOfferFile(model.AWS_SERVICE_CODE_RDS)
+			if test.expected == "" {
+				if err == nil {
+					t.Errorf("found %s, want error", filename)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := filepath.Join(dir, filepath.FromSlash(test.expected)); filename != expected {
+				t.Errorf("found %s, want %s", filename, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	// Model
+	"aws-price-scanner/model"
+)
+
+// Columns of price terms in offer file (CSV), the other columns are product attributes
+const (
+	COLUMN_SKU                   = "SKU"
+	COLUMN_OFFER_TERM_CODE       = "OfferTermCode"
+	COLUMN_RATE_CODE             = "RateCode"
+	COLUMN_TERM_TYPE             = "TermType"
+	COLUMN_PRICE_DESCRIPTION     = "PriceDescription"
+	COLUMN_EFFECTIVE_DATE        = "EffectiveDate"
+	COLUMN_STARTING_RANGE        = "StartingRange"
+	COLUMN_ENDING_RANGE          = "EndingRange"
+	COLUMN_UNIT                  = "Unit"
+	COLUMN_PRICE_PER_UNIT        = "PricePerUnit"
+	COLUMN_CURRENCY              = "Currency"
+	COLUMN_RELATED_TO            = "RelatedTo"
+	COLUMN_LEASE_CONTRACT_LENGTH = "LeaseContractLength"
+	COLUMN_PURCHASE_OPTION       = "PurchaseOption"
+	COLUMN_OFFERING_CLASS        = "OfferingClass"
+	COLUMN_PRODUCT_FAMILY        = "Product Family"
+)
+
+var termColumns = map[string]bool{
+	COLUMN_SKU: true, COLUMN_OFFER_TERM_CODE: true, COLUMN_RATE_CODE: true, COLUMN_TERM_TYPE: true,
+	COLUMN_PRICE_DESCRIPTION: true, COLUMN_EFFECTIVE_DATE: true, COLUMN_STARTING_RANGE: true, COLUMN_ENDING_RANGE: true,
+	COLUMN_UNIT: true, COLUMN_PRICE_PER_UNIT: true, COLUMN_CURRENCY: true, COLUMN_RELATED_TO: true,
+	COLUMN_LEASE_CONTRACT_LENGTH: true, COLUMN_PURCHASE_OPTION: true, COLUMN_OFFERING_CLASS: true, COLUMN_PRODUCT_FAMILY: true,
+}
+
+// Attribute names (JSON) of the CSV columns that do not follow the camel case convention
+var attributeNames = map[string]string{
+	"AvailabilityZone":           "availabilityzone",
+	"CapacityStatus":             "capacitystatus",
+	"ClassicNetworkingSupport":   "classicnetworkingsupport",
+	"Instance Capacity":          "instancecapacity",
+	"MarketOption":               "marketoption",
+	"Max IOPS Burst Performance": "maxIopsBurstPerformance",
+	"Max IOPS/volume":            "maxIopsvolume",
+	"Max throughput/volume":      "maxThroughputvolume",
+	"PreInstalled S/W":           "preInstalledSw",
+	"serviceCode":                "servicecode",
+	"serviceName":                "servicename",
+	"usageType":                  "usagetype",
+	"vCPU":                       "vcpu",
+}
+
+/*
+ * Read offer file (CSV), so that only the matching products are kept in memory
+ * @param			ctx {context.Context} context
+ * @param			r {io.Reader} offer file
+ * @param			filters {[]model.Filter} product filters
+ * @response	{map[string]*model.RawData} products by sku
+ * @response	{error} error object (contain nil)
+ */
+func readCSV(ctx context.Context, r io.Reader, filters []model.Filter) (map[string]*model.RawData, error) {
+	reader := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	// Read metadata until header
+	var version string
+	var header []string
+	for header == nil {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil, errors.New("Not found header in offer file")
+		} else if err != nil {
+			return nil, err
+		}
+		if len(row) > 1 && row[0] == "Version" {
+			version = row[1]
+		} else if len(row) > 0 && row[0] == COLUMN_SKU {
+			header = append([]string{}, row...)
+		}
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	// Read rows (a row is a price dimension of a product)
+	products := make(map[string]*model.RawData)
+	rejected := make(map[string]bool)
+	for cnt := 0; ; cnt++ {
+		if cnt%1000 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		sku := value(row, COLUMN_SKU)
+		if rejected[sku] {
+			continue
+		}
+		// Set product
+		rawData, ok := products[sku]
+		if !ok {
+			rawData = &model.RawData{Version: version}
+			rawData.Product.Sku = sku
+			rawData.Product.ProductFamily = value(row, COLUMN_PRODUCT_FAMILY)
+			rawData.Product.Attributes = make(map[string]string)
+			for i, name := range header {
+				if !termColumns[name] && i < len(row) && row[i] != "" {
+					rawData.Product.Attributes[attributeName(name)] = row[i]
+				}
+			}
+			if !matchFilters(filters, *rawData) {
+				rejected[sku] = true
+				continue
+			}
+			products[sku] = rawData
+		}
+		// Set term
+		var terms map[string]model.RawTerm
+		switch value(row, COLUMN_TERM_TYPE) {
+		case "OnDemand":
+			if rawData.Terms.OnDemand == nil {
+				rawData.Terms.OnDemand = make(map[string]model.RawTerm)
+			}
+			terms = rawData.Terms.OnDemand
+		case "Reserved":
+			if rawData.Terms.Reserved == nil {
+				rawData.Terms.Reserved = make(map[string]model.RawTerm)
+			}
+			terms = rawData.Terms.Reserved
+		default:
+			continue
+		}
+		termKey := sku + "." + value(row, COLUMN_OFFER_TERM_CODE)
+		term, ok := terms[termKey]
+		if !ok {
+			term = model.RawTerm{
+				EffectiveDate:   value(row, COLUMN_EFFECTIVE_DATE),
+				OfferTermCode:   value(row, COLUMN_OFFER_TERM_CODE),
+				PriceDimensions: make(map[string]model.RawPriceDimension),
+				Sku:             sku,
+				TermAttributes:  make(map[string]string),
+			}
+			for _, name := range []string{COLUMN_LEASE_CONTRACT_LENGTH, COLUMN_PURCHASE_OPTION, COLUMN_OFFERING_CLASS} {
+				if v := value(row, name); v != "" {
+					term.TermAttributes[name] = v
+				}
+			}
+		}
+		// Set price dimension
+		rateCode := value(row, COLUMN_RATE_CODE)
+		term.PriceDimensions[rateCode] = model.RawPriceDimension{
+			BeginRange:   value(row, COLUMN_STARTING_RANGE),
+			Description:  value(row, COLUMN_PRICE_DESCRIPTION),
+			EndRange:     value(row, COLUMN_ENDING_RANGE),
+			PricePerUnit: map[string]string{value(row, COLUMN_CURRENCY): value(row, COLUMN_PRICE_PER_UNIT)},
+			RateCode:     rateCode,
+			Unit:         value(row, COLUMN_UNIT),
+		}
+		terms[termKey] = term
+	}
+	return products, nil
+}
+
+/*
+ * Convert CSV column name to attribute name of JSON (e.g. "Instance Type" to "instanceType")
+ * @param			column {string} column name
+ * @response	{string} attribute name
+ */
+func attributeName(column string) string {
+	if name, ok := attributeNames[column]; ok {
+		return name
+	}
+	words := strings.Fields(column)
+	if len(words) == 1 {
+		// Single word keeps its case except the first letter (e.g. "volumeApiName", "Tenancy")
+		runes := []rune(words[0])
+		runes[0] = unicode.ToLower(runes[0])
+		return string(runes)
+	}
+	var name strings.Builder
+	for i, word := range words {
+		word = strings.ToLower(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, word))
+		if i > 0 && word != "" {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		name.WriteString(word)
+	}
+	return name.String()
+}
@@ -0,0 +1,154 @@
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	// Model
+	"aws-price-scanner/model"
+)
+
+// Product of offer file
+type offerProduct struct {
+	Attributes    map[string]string `json:"attributes"`
+	ProductFamily string            `json:"productFamily"`
+	Sku           string            `json:"sku"`
+}
+
+/*
+ * Read offer file (JSON) as a stream, so that only the matching products are kept in memory
+ * @param			ctx {context.Context} context
+ * @param			r {io.Reader} offer file
+ * @param			filters {[]model.Filter} product filters
+ * @response	{map[string]*model.RawData} products by sku
+ * @response	{error} error object (contain nil)
+ */
+func readJSON(ctx context.Context, r io.Reader, filters []model.Filter) (map[string]*model.RawData, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, 1<<20))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var version string
+	var products map[string]*model.RawData
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "version":
+			if err := dec.Decode(&version); err != nil {
+				return nil, err
+			}
+		case "products":
+			if products, err = readJSONProducts(ctx, dec, filters, version); err != nil {
+				return nil, err
+			}
+		case "terms":
+			if products == nil {
+				return nil, errors.New("Products must precede terms in offer file")
+			}
+			if err := readJSONTerms(ctx, dec, products); err != nil {
+				return nil, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if products == nil {
+		return nil, errors.New("Not found products in offer file")
+	}
+	return products, nil
+}
+
+func readJSONProducts(ctx context.Context, dec *json.Decoder, filters []model.Filter, version string) (map[string]*model.RawData, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	products := make(map[string]*model.RawData)
+	for cnt := 0; dec.More(); cnt++ {
+		if cnt%1000 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		var product offerProduct
+		if err := dec.Decode(&product); err != nil {
+			return nil, err
+		}
+		// Check filters
+		rawData := &model.RawData{Version: version}
+		rawData.Product.Attributes = product.Attributes
+		rawData.Product.ProductFamily = product.ProductFamily
+		rawData.Product.Sku = product.Sku
+		if matchFilters(filters, *rawData) {
+			products[product.Sku] = rawData
+		}
+	}
+	return products, expectDelim(dec, '}')
+}
+
+func readJSONTerms(ctx context.Context, dec *json.Decoder, products map[string]*model.RawData) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		termType, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for cnt := 0; dec.More(); cnt++ {
+			if cnt%1000 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			sku, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			rawData, ok := products[fmt.Sprint(sku)]
+			if !ok {
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return err
+				}
+				continue
+			}
+			var terms map[string]model.RawTerm
+			if err := dec.Decode(&terms); err != nil {
+				return err
+			}
+			switch termType {
+			case "OnDemand":
+				rawData.Terms.OnDemand = terms
+			case "Reserved":
+				rawData.Terms.Reserved = terms
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	} else if token != delim {
+		return fmt.Errorf("Invalid offer file (expected %q, found %v)", delim, token)
+	}
+	return nil
+}
@@ -3,9 +3,12 @@ package pricing
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	// AWS
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	awsPricing "github.com/aws/aws-sdk-go-v2/service/pricing"
 	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
@@ -14,11 +17,17 @@ import (
 	"aws-price-scanner/model"
 	// Process
 	"aws-price-scanner/process"
+	// Output sink
+	"aws-price-scanner/sink"
 )
 
 const (
 	AWS_REGION     = "ap-south-1"
 	FORMAT_VERSION = "aws_v1"
+	// Maximum number of attempts for a request (throttling and transient errors are retried)
+	MAX_ATTEMPTS = 8
+	// Maximum delay between attempts
+	MAX_BACKOFF = 30 * time.Second
 )
 
 var svc *awsPricing.Client
@@ -35,7 +44,7 @@ type AwsService struct {
  */
 func Configure(ctx context.Context) error {
 	// Configuration for AWS
-	if cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(AWS_REGION)); err != nil {
+	if cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(AWS_REGION), config.WithRetryer(func() aws.Retryer { return newRetryer() })); err != nil {
 		return err
 	} else {
 		// Create service client for aws pricing
@@ -44,6 +53,20 @@ func Configure(ctx context.Context) error {
 	}
 }
 
+// Retry throttling and transient errors with jittered exponential backoff (a scan of big services requests thousands of pages)
+func newRetryer(optFns ...func(*retry.StandardOptions)) aws.Retryer {
+	return retry.NewStandard(append([]func(*retry.StandardOptions){func(o *retry.StandardOptions) {
+		o.MaxAttempts = MAX_ATTEMPTS
+		o.MaxBackoff = MAX_BACKOFF
+		o.Retryables = append(o.Retryables, retry.RetryableErrorCode{
+			Codes: map[string]struct{}{"InternalErrorException": {}},
+		})
+		// No retry quota (the default quota runs out under sustained throttling, then every retry fails at once)
+		o.RetryCost = 0
+		o.RetryTimeoutCost = 0
+	}}, optFns...)...)
+}
+
 /*
  * Get a list of service code to use AWS pricing SDK
  * @param 		ctx {context.Context} context
@@ -142,130 +165,19 @@ func (as AwsService) GetAttributeValues(attribute string) ([]string, error) {
 }
 
 /*
- * [Method] Get a list of price information for service (store output in AWS S3)
+ * [Method] Get a list of price information for service (write output to sink)
+ * @param			output {sink.Sink} output destination
+ * @response	{*model.ScanResult} scan result (contain nil)
+ * @response	{error} error object (contain nil)
  */
-func (as AwsService) GetPriceList() {
-	// Set filters
-	var filters []types.Filter
-	switch as.ServiceCode {
-	case model.AWS_SERVICE_CODE_DYNAMODB:
-		filters = []types.Filter{{
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("OnDemand"),
-		}}
-	case model.AWS_SERVICE_CODE_EBS:
-		filters = []types.Filter{{
-			Field: aws.String("productFamily"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Storage"),
-		}}
-	case model.AWS_SERVICE_CODE_EC2:
-		filters = []types.Filter{{
-			Field: aws.String("currentGeneration"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Yes"),
-		}, {
-			Field: aws.String("capacitystatus"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Used"),
-		}, {
-			Field: aws.String("marketoption"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("OnDemand"),
-		}, {
-			Field: aws.String("tenancy"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Shared"),
-		}}
-	case model.AWS_SERVICE_CODE_ECS:
-		filters = []types.Filter{{
-			Field: aws.String("locationType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("AWS Region"),
-		}, {
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("OnDemand"),
-		}, {
-			Field: aws.String("tenancy"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Shared"),
-		}, {
-			Field: aws.String("productFamily"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Compute"),
-		}}
-	case model.AWS_SERVICE_CODE_EFS:
-		filters = []types.Filter{{
-			Field: aws.String("locationType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("AWS Region"),
-		}, {
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("OnDemand"),
-		}}
-	case model.AWS_SERVICE_CODE_ELB:
-		filters = []types.Filter{{
-			Field: aws.String("locationType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("AWS Region"),
-		}, {
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("OnDemand"),
-		}}
-	case model.AWS_SERVICE_CODE_RDS:
-		filters = []types.Filter{{
-			Field: aws.String("currentGeneration"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Yes"),
-		}, {
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("OnDemand"),
-		}}
-	case model.AWS_SERVICE_CODE_LAMBDA:
-		filters = []types.Filter{{
-			Field: aws.String("locationType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("AWS Region"),
-		}, {
-			Field: aws.String("productFamily"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("Serverless"),
-		}}
-	case model.AWS_SERVICE_CODE_S3:
-		filters = []types.Filter{{
-			Field: aws.String("locationType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("AWS Region"),
-		}, {
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("onDemand"),
-		}}
-	case model.AWS_SERVICE_CODE_VPC:
-		filters = []types.Filter{{
-			Field: aws.String("locationType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("AWS Region"),
-		}, {
-			Field: aws.String("termType"),
-			Type:  types.FilterTypeTermMatch,
-			Value: aws.String("onDemand"),
-		}}
+func (as AwsService) GetPriceList(output sink.Sink) (*model.ScanResult, error) {
+	// Find service definition
+	def, ok := process.Lookup(as.ServiceCode)
+	if !ok {
+		return nil, errors.New("Not match service code")
 	}
-
-	// {
-	// 	Field: aws.String("location"),
-	// 	Type:  types.FilterTypeTermMatch,
-	// 	Value: aws.String("Asia Pacific (Seoul)"),
-	// }
-
 	// Execute command
-	process.OperatePriceCommand(as.Context, svc, as.ServiceCode, filters)
+	return process.OperatePriceCommand(as.Context, Source{}, def, output)
 }
 
 func (as AwsService) GetPriceListForTest() error {
@@ -286,6 +198,27 @@ func (as AwsService) GetPriceListForTest() error {
 		Type:  types.FilterTypeTermMatch,
 		Value: aws.String("Shared"),
 	}}
+	// Find service code for AWS pricing API
+	serviceCode := as.ServiceCode
+	if def, ok := process.Lookup(as.ServiceCode); ok {
+		serviceCode = def.PricingServiceCode
+	}
+	// Set input parameter
+	input := &awsPricing.GetProductsInput{
+		Filters:       filters,
+		FormatVersion: aws.String(FORMAT_VERSION),
+		MaxResults:    int32(20),
+		ServiceCode:   aws.String(serviceCode),
+	}
+
+	output, err := svc.GetProducts(as.Context, input)
+	if err != nil {
+		return err
+	}
 
-	return process.OperatePriceCommandForTest(as.Context, svc, as.ServiceCode, filters)
+	for _, data := range output.PriceList {
+		fmt.Println(data)
+		fmt.Println()
+	}
+	return nil
 }
@@ -0,0 +1,80 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	// AWS
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsPricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+
+	// Model
+	"aws-price-scanner/model"
+	// Process
+	"aws-price-scanner/process"
+)
+
+// HTTP client of AWS pricing API that throttles every page several times before it responds
+type throttlingHTTPClient struct {
+	pages      int
+	throttles  int
+	attempts   int
+	throttled  int
+	pagesTaken int
+}
+
+func (tc *throttlingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	tc.attempts++
+	if tc.attempts <= tc.throttles {
+		tc.throttled++
+		return newHTTPResponse(http.StatusBadRequest, `{"__type":"ThrottlingException","message":"Rate exceeded"}`), nil
+	}
+	tc.attempts = 0
+	tc.pagesTaken++
+	body := fmt.Sprintf(`{"FormatVersion":"aws_v1","PriceList":["{\"product\":{\"sku\":\"SKU%d\",\"attributes\":{}},\"terms\":{}}"]`, tc.pagesTaken)
+	if tc.pagesTaken < tc.pages {
+		body += fmt.Sprintf(`,"NextToken":"token%d"`, tc.pagesTaken)
+	}
+	return newHTTPResponse(http.StatusOK, body+"}"), nil
+}
+
+func newHTTPResponse(status int, body string) *http.Response {
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-amz-json-1.1")
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestRetryerCompletesUnderSustainedThrottling(t *testing.T) {
+	httpClient := &throttlingHTTPClient{pages: 40, throttles: 4}
+	client := awsPricing.New(awsPricing.Options{
+		Credentials: aws.AnonymousCredentials{},
+		HTTPClient:  httpClient,
+		Region:      AWS_REGION,
+		Retryer: newRetryer(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(attempt int, err error) (time.Duration, error) { return 0, nil })
+		}),
+	})
+
+	products := 0
+	source := Source{Client: client}
+	err := source.Products(context.Background(), process.ServiceDefinition{Code: "Test", PricingServiceCode: "AmazonTest"}, func(page []model.RawData) error {
+		products += len(page)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scan failed after %d throttles: %v", httpClient.throttled, err)
+	}
+	if httpClient.throttled != 160 || products != 40 {
+		t.Errorf("throttles %d, products %d, want 160 and 40", httpClient.throttled, products)
+	}
+}
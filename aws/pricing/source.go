@@ -0,0 +1,360 @@
+package pricing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	// AWS
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsPricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+
+	// Model
+	"aws-price-scanner/model"
+	// Process
+	"aws-price-scanner/process"
+	// Output sink
+	"aws-price-scanner/sink"
+)
+
+const (
+	PAGE_SIZE = 100
+	// Maximum age of a checkpoint to resume from (an older scan starts over, so it does not replay outdated prices)
+	CHECKPOINT_MAX_AGE = 24 * time.Hour
+)
+
+// Client of AWS pricing API to request products (implemented by *awsPricing.Client)
+type ProductsClient interface {
+	GetProducts(ctx context.Context, params *awsPricing.GetProductsInput, optFns ...func(*awsPricing.Options)) (*awsPricing.GetProductsOutput, error)
+}
+
+// Source requests products from AWS pricing API (call Configure before use, unless Client is set)
+type Source struct {
+	// Client of AWS pricing API (default: the client created by Configure)
+	Client ProductsClient
+	// Directory to store checkpoint of scan, so that an interrupted scan resumes (no checkpoint if empty)
+	StateDir string
+	// Called when a request resumes from checkpoint with the number of pages requested before (optional)
+	OnResume func(def process.ServiceDefinition, pages int)
+}
+
+// Checkpoint of scan (the products of requested pages are stored in spool file)
+type checkpoint struct {
+	ServiceCode        string         `json:"serviceCode"`
+	PricingServiceCode string         `json:"pricingServiceCode"`
+	Filters            []model.Filter `json:"filters"`
+	NextToken          string         `json:"nextToken"`
+	Pages              int            `json:"pages"`
+	SpoolSize          int64          `json:"spoolSize"`
+	Completed          bool           `json:"completed,omitempty"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+}
+
+/*
+ * [Method] Request products of service page by page (resume from checkpoint, the client retries throttling and transient errors)
+ * @param			ctx {context.Context} context
+ * @param			def {process.ServiceDefinition} service definition
+ * @param			emit {func([]model.RawData) error} callback for a page of products
+ * @response	{error} error object (contain nil)
+ */
+func (s Source) Products(ctx context.Context, def process.ServiceDefinition, emit func(page []model.RawData) error) error {
+	// Set input parameter
+	input := &awsPricing.GetProductsInput{
+		Filters:       transformFilters(def.Filters),
+		FormatVersion: aws.String(FORMAT_VERSION),
+		MaxResults:    int32(PAGE_SIZE),
+		ServiceCode:   aws.String(def.PricingServiceCode),
+	}
+
+	var client ProductsClient = svc
+	if s.Client != nil {
+		client = s.Client
+	}
+
+	// Resume from checkpoint
+	var state *checkpointState
+	if s.StateDir != "" {
+		var err error
+		if state, err = openCheckpoint(ctx, s.StateDir, def); err != nil {
+			return err
+		}
+		defer state.close()
+		if state.NextToken != "" || state.Completed {
+			if s.OnResume != nil {
+				s.OnResume(def, state.Pages)
+			}
+			if err := state.replay(emit); err != nil {
+				return err
+			}
+			// The request is done (kept until the output of the scan is stored)
+			if state.Completed {
+				return nil
+			}
+			input.NextToken = aws.String(state.NextToken)
+		}
+	}
+
+	// Request pages
+	for {
+		output, err := client.GetProducts(ctx, input)
+		if err != nil {
+			// The next token is no longer valid, so the request starts over (products emitted again merge with the same sku)
+			var expiredErr *types.ExpiredNextTokenException
+			var invalidErr *types.InvalidNextTokenException
+			if input.NextToken != nil && (errors.As(err, &expiredErr) || errors.As(err, &invalidErr)) {
+				input.NextToken = nil
+				if state != nil {
+					if rerr := state.reset(); rerr != nil {
+						return rerr
+					}
+				}
+				continue
+			}
+			return err
+		}
+		// Extract price data
+		page, err := extractPriceData(output.PriceList)
+		if err != nil {
+			return err
+		}
+		// Escape logic (same as paginator, stop on duplicate token)
+		prevToken := input.NextToken
+		input.NextToken = output.NextToken
+		if prevToken != nil && input.NextToken != nil && *prevToken == *input.NextToken {
+			input.NextToken = nil
+		}
+		// Save checkpoint before emit (spool holds the products of the page, no next token if the request is done)
+		if state != nil {
+			if err := state.save(ctx, output.PriceList, aws.ToString(input.NextToken)); err != nil {
+				return err
+			}
+		}
+		if err := emit(page); err != nil {
+			return err
+		}
+		if input.NextToken == nil {
+			break
+		}
+	}
+
+	// Completed (the checkpoint is removed by Complete)
+	return nil
+}
+
+/*
+ * [Method] Remove checkpoint of a request once the output of the scan is stored
+ * @param			ctx {context.Context} context
+ * @param			def {process.ServiceDefinition} service definition of the request
+ * @response	{error} error object (contain nil)
+ */
+func (s Source) Complete(ctx context.Context, def process.ServiceDefinition) error {
+	if s.StateDir == "" {
+		return nil
+	}
+	state := newCheckpointState(sink.Local{Directory: s.StateDir}, def)
+	return state.remove()
+}
+
+func transformFilters(filters []model.Filter) []types.Filter {
+	result := make([]types.Filter, len(filters))
+	for i, filter := range filters {
+		result[i] = types.Filter{
+			Field: aws.String(filter.Field),
+			Type:  types.FilterTypeTermMatch,
+			Value: aws.String(filter.Value),
+		}
+	}
+	return result
+}
+
+func extractPriceData(priceList []string) ([]model.RawData, error) {
+	result := make([]model.RawData, len(priceList))
+	for i, data := range priceList {
+		if err := json.Unmarshal([]byte(data), &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Checkpoint with its state and spool files
+type checkpointState struct {
+	checkpoint
+	states    *sink.Local
+	stateName string
+	spoolName string
+	spool     *os.File
+}
+
+func newCheckpointState(states sink.Local, def process.ServiceDefinition) *checkpointState {
+	return &checkpointState{
+		checkpoint: checkpoint{
+			ServiceCode:        def.Code,
+			PricingServiceCode: def.PricingServiceCode,
+			Filters:            def.Filters,
+		},
+		states:    &states,
+		stateName: def.Code + ".checkpoint.json",
+		spoolName: filepath.Join(states.Directory, def.Code+".spool.jsonl"),
+	}
+}
+
+func openCheckpoint(ctx context.Context, stateDir string, def process.ServiceDefinition) (*checkpointState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	states, err := sink.NewLocal(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	state := newCheckpointState(*states, def)
+
+	// Load previous checkpoint (ignore it if it is invalid, too old or the scan is not the same, so the request starts over)
+	if content, err := ioutil.ReadFile(filepath.Join(stateDir, state.stateName)); err == nil {
+		var prev checkpoint
+		if err := json.Unmarshal(content, &prev); err == nil && time.Since(prev.UpdatedAt) <= CHECKPOINT_MAX_AGE && prev.ServiceCode == def.Code && prev.PricingServiceCode == def.PricingServiceCode && sameFilters(prev.Filters, def.Filters) {
+			state.checkpoint = prev
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Open spool (drop products after the checkpoint)
+	if state.spool, err = os.OpenFile(state.spoolName, os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		return nil, err
+	}
+	if state.NextToken == "" && !state.Completed {
+		state.SpoolSize = 0
+	}
+	if err := state.spool.Truncate(state.SpoolSize); err != nil {
+		state.spool.Close()
+		return nil, err
+	}
+	if _, err := state.spool.Seek(state.SpoolSize, io.SeekStart); err != nil {
+		state.spool.Close()
+		return nil, err
+	}
+	return state, nil
+}
+
+func sameFilters(a []model.Filter, b []model.Filter) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Emit the products of spool (the pages requested before the checkpoint)
+func (cs *checkpointState) replay(emit func(page []model.RawData) error) error {
+	reader := bufio.NewReader(io.NewSectionReader(cs.spool, 0, cs.SpoolSize))
+	priceList := make([]string, 0, PAGE_SIZE)
+	flush := func() error {
+		if len(priceList) == 0 {
+			return nil
+		}
+		page, err := extractPriceData(priceList)
+		if err != nil {
+			return err
+		}
+		priceList = priceList[:0]
+		return emit(page)
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 1 {
+			var data string
+			if jerr := json.Unmarshal([]byte(line), &data); jerr != nil {
+				return jerr
+			}
+			if priceList = append(priceList, data); len(priceList) >= PAGE_SIZE {
+				if ferr := flush(); ferr != nil {
+					return ferr
+				}
+			}
+		}
+		if err == io.EOF {
+			return flush()
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Append the products of a page to spool, then save the next token (empty if the request is done)
+func (cs *checkpointState) save(ctx context.Context, priceList []string, nextToken string) error {
+	writer := bufio.NewWriter(cs.spool)
+	for _, data := range priceList {
+		line, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		writer.Write(line)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := cs.spool.Sync(); err != nil {
+		return err
+	}
+	size, err := cs.spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	cs.NextToken = nextToken
+	cs.Completed = nextToken == ""
+	cs.Pages++
+	cs.SpoolSize = size
+	cs.UpdatedAt = time.Now().UTC()
+	artifact, err := sink.NewJSONArtifact(cs.stateName, cs.checkpoint, nil)
+	if err != nil {
+		return err
+	}
+	_, err = cs.states.Write(ctx, artifact)
+	return err
+}
+
+// Drop the products of spool and the next token, so the request starts over (the checkpoint is saved again with the next page)
+func (cs *checkpointState) reset() error {
+	if err := os.Remove(filepath.Join(cs.states.Directory, cs.stateName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := cs.spool.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := cs.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	cs.NextToken = ""
+	cs.Completed = false
+	cs.Pages = 0
+	cs.SpoolSize = 0
+	return nil
+}
+
+func (cs *checkpointState) remove() error {
+	cs.close()
+	if err := os.Remove(filepath.Join(cs.states.Directory, cs.stateName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(cs.spoolName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (cs *checkpointState) close() {
+	if cs.spool != nil {
+		cs.spool.Close()
+		cs.spool = nil
+	}
+}
@@ -0,0 +1,244 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	// AWS
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsPricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+
+	// Model
+	"aws-price-scanner/model"
+	// Process
+	"aws-price-scanner/process"
+)
+
+// Client that serves pages of products by next token (fails once on the page of failToken)
+type fakeProductsClient struct {
+	pages       map[string][]string
+	next        map[string]string
+	failToken   string
+	expireToken string
+	requested   []string
+}
+
+func newFakeProductsClient(pageCount int) *fakeProductsClient {
+	fc := &fakeProductsClient{pages: map[string][]string{}, next: map[string]string{}}
+	for i := 0; i < pageCount; i++ {
+		token := ""
+		if i > 0 {
+			token = fmt.Sprintf("token%d", i)
+		}
+		fc.pages[token] = []string{
+			fmt.Sprintf(`{"product":{"sku":"SKU%d-1","attributes":{}},"terms":{}}`, i),
+			fmt.Sprintf(`{"product":{"sku":"SKU%d-2","attributes":{}},"terms":{}}`, i),
+		}
+		if i+1 < pageCount {
+			fc.next[token] = fmt.Sprintf("token%d", i+1)
+		}
+	}
+	return fc
+}
+
+func (fc *fakeProductsClient) GetProducts(ctx context.Context, params *awsPricing.GetProductsInput, optFns ...func(*awsPricing.Options)) (*awsPricing.GetProductsOutput, error) {
+	token := aws.ToString(params.NextToken)
+	fc.requested = append(fc.requested, token)
+	if token != "" && token == fc.failToken {
+		fc.failToken = ""
+		return nil, errors.New("connection reset")
+	}
+	if token != "" && token == fc.expireToken {
+		fc.expireToken = ""
+		return nil, &types.ExpiredNextTokenException{Message: aws.String("Next token expired")}
+	}
+	output := &awsPricing.GetProductsOutput{PriceList: fc.pages[token]}
+	if next, ok := fc.next[token]; ok {
+		output.NextToken = aws.String(next)
+	}
+	return output, nil
+}
+
+func collectSkus(skus *[]string) func(page []model.RawData) error {
+	return func(page []model.RawData) error {
+		for _, rawData := range page {
+			*skus = append(*skus, rawData.Product.Sku)
+		}
+		return nil
+	}
+}
+
+func TestSourceResumesFromCheckpoint(t *testing.T) {
+	stateDir := t.TempDir()
+	def := process.ServiceDefinition{
+		Code:               "Test",
+		PricingServiceCode: "AmazonTest",
+		Filters:            []model.Filter{{Field: "location", Value: "US East (N. Virginia)"}},
+	}
+	client := newFakeProductsClient(4)
+	client.failToken = "token2"
+	want := []string{"SKU0-1", "SKU0-2", "SKU1-1", "SKU1-2", "SKU2-1", "SKU2-2", "SKU3-1", "SKU3-2"}
+
+	// The scan fails after 2 pages
+	var skus []string
+	source := Source{Client: client, StateDir: stateDir}
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err == nil {
+		t.Fatal("expected error of failing request")
+	}
+	if !reflect.DeepEqual(skus, want[:4]) {
+		t.Errorf("products %v, want %v", skus, want[:4])
+	}
+
+	// The scan resumes from the page that failed
+	skus = nil
+	resumed := -1
+	client.requested = nil
+	source.OnResume = func(def process.ServiceDefinition, pages int) { resumed = pages }
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err != nil {
+		t.Fatal(err)
+	}
+	if resumed != 2 {
+		t.Errorf("resumed with %d pages, want 2", resumed)
+	}
+	if !reflect.DeepEqual(client.requested, []string{"token2", "token3"}) {
+		t.Errorf("requested tokens %v, want token2 and token3", client.requested)
+	}
+	if !reflect.DeepEqual(skus, want) {
+		t.Errorf("products %v, want %v", skus, want)
+	}
+
+	// The done request is replayed without requests until it is completed
+	skus = nil
+	client.requested = nil
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.requested) != 0 || !reflect.DeepEqual(skus, want) {
+		t.Errorf("requested tokens %v, products %v after done, want no request and every product", client.requested, skus)
+	}
+
+	// Complete removes the checkpoint and spool
+	if err := source.Complete(context.Background(), def); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Test.checkpoint.json", "Test.spool.jsonl"} {
+		if _, err := os.Stat(filepath.Join(stateDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s is not removed: %v", name, err)
+		}
+	}
+	client.requested = nil
+	if err := source.Products(context.Background(), def, func(page []model.RawData) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.requested) != 4 {
+		t.Errorf("requested tokens %v after complete, want a new scan", client.requested)
+	}
+}
+
+func TestSourceIgnoresCheckpointOfOtherScan(t *testing.T) {
+	stateDir := t.TempDir()
+	def := process.ServiceDefinition{Code: "Test", PricingServiceCode: "AmazonTest"}
+	client := newFakeProductsClient(3)
+	client.failToken = "token2"
+	source := Source{Client: client, StateDir: stateDir}
+	if err := source.Products(context.Background(), def, func(page []model.RawData) error { return nil }); err == nil {
+		t.Fatal("expected error of failing request")
+	}
+
+	// The filters changed, so the scan starts over
+	def.Filters = []model.Filter{{Field: "location", Value: "EU (Ireland)"}}
+	client.requested = nil
+	var skus []string
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(client.requested, []string{"", "token1", "token2"}) || len(skus) != 6 {
+		t.Errorf("requested tokens %v, products %v, want a new scan", client.requested, skus)
+	}
+}
+
+func TestSourceCanceledBeforeCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	source := Source{Client: newFakeProductsClient(1), StateDir: t.TempDir()}
+	err := source.Products(ctx, process.ServiceDefinition{Code: "Test"}, func(page []model.RawData) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, want context canceled", err)
+	}
+}
+
+func TestSourceStartsOverOnExpiredToken(t *testing.T) {
+	stateDir := t.TempDir()
+	def := process.ServiceDefinition{Code: "Test", PricingServiceCode: "AmazonTest"}
+	client := newFakeProductsClient(4)
+	client.expireToken = "token2"
+
+	// The request starts over, the products of the first pages are emitted again
+	var skus []string
+	source := Source{Client: client, StateDir: stateDir}
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"", "token1", "token2", "", "token1", "token2", "token3"}; !reflect.DeepEqual(client.requested, want) {
+		t.Errorf("requested tokens %v, want %v", client.requested, want)
+	}
+	if len(skus) != 12 {
+		t.Errorf("products %v, want 12 (4 emitted again)", skus)
+	}
+
+	// The spool holds the products of the request once
+	skus = nil
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"SKU0-1", "SKU0-2", "SKU1-1", "SKU1-2", "SKU2-1", "SKU2-2", "SKU3-1", "SKU3-2"}; !reflect.DeepEqual(skus, want) {
+		t.Errorf("replayed products %v, want %v", skus, want)
+	}
+}
+
+func TestSourceIgnoresOldCheckpoint(t *testing.T) {
+	stateDir := t.TempDir()
+	def := process.ServiceDefinition{Code: "Test", PricingServiceCode: "AmazonTest"}
+	client := newFakeProductsClient(3)
+	client.failToken = "token2"
+	source := Source{Client: client, StateDir: stateDir}
+	if err := source.Products(context.Background(), def, func(page []model.RawData) error { return nil }); err == nil {
+		t.Fatal("expected error of failing request")
+	}
+
+	// Age the checkpoint beyond the maximum age
+	filename := filepath.Join(stateDir, "Test.checkpoint.json")
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state checkpoint
+	if err := json.Unmarshal(content, &state); err != nil {
+		t.Fatal(err)
+	}
+	state.UpdatedAt = time.Now().Add(-CHECKPOINT_MAX_AGE - time.Hour)
+	if content, err = json.Marshal(state); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client.requested = nil
+	var skus []string
+	if err := source.Products(context.Background(), def, collectSkus(&skus)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(client.requested, []string{"", "token1", "token2"}) || len(skus) != 6 {
+		t.Errorf("requested tokens %v, products %v, want a new scan", client.requested, skus)
+	}
+}
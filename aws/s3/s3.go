@@ -3,7 +3,6 @@ package s3
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"path"
 
@@ -11,71 +10,83 @@ import (
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	// Output sink
+	"aws-price-scanner/sink"
 )
 
-var (
-	svc *s3.Client
+// Client of AWS S3 to upload objects (implemented by *s3.Client)
+type PutObjectClient interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
 
-	bucketName    string
-	directoryName string
-)
+// Sink stores output artifacts in an AWS S3 bucket
+type Sink struct {
+	Client    PutObjectClient
+	Bucket    string
+	Directory string
+}
 
 /*
  * AWS s3 configuration
  * @param 		ctx {context.Context} context
+ * @response	{*s3.Client} service client for aws s3
  * @response	{error} error object (contain nil)
  */
-func Configure(ctx context.Context) error {
+func Configure(ctx context.Context) (*s3.Client, error) {
 	// Configuration for AWS
 	if cfg, err := config.LoadDefaultConfig(ctx); err != nil {
-		return err
+		return nil, err
 	} else {
 		// Create service client for aws s3
-		svc = s3.NewFromConfig(cfg)
-		return nil
+		return s3.NewFromConfig(cfg), nil
 	}
 }
 
 /*
- * Set aws s3 bucket and directory to store output
- * @param			bName {string} bucket name
- * @param			dName {string} directory name in bucket
+ * New sink to store output in aws s3 bucket and directory
+ * @param			client {PutObjectClient} service client for aws s3 (e.g. client of Configure)
+ * @param			bucket {string} bucket name
+ * @param			directory {string} directory name in bucket
+ * @response	{*Sink} sink object
  * @response	{error} error object (contain nil)
  */
-func SetPath(bName string, dName string) error {
-	// Set s3 bucket
-	if bucketName = bName; bucketName == "" {
-		return errors.New("Setting the AWS S3 bucket name is mandatory")
+func NewSink(client PutObjectClient, bucket string, directory string) (*Sink, error) {
+	// Check s3 client and bucket
+	if client == nil {
+		return nil, errors.New("Setting the AWS S3 client is mandatory")
+	} else if bucket == "" {
+		return nil, errors.New("Setting the AWS S3 bucket name is mandatory")
 	}
-	// Set directory in s3
-	directoryName = dName
-	return nil
+	return &Sink{Client: client, Bucket: bucket, Directory: directory}, nil
 }
 
 /*
- * Upload object to aws s3
+ * [Method] Upload artifact to aws s3
  * @param			ctx {context.Context} context
- * @param			filename {string} output file name
- * @param			data {interface{}} output data
+ * @param			artifact {sink.Artifact} output artifact
+ * @response	{string} object key of uploaded output
  * @response	{error} error object (contain nil)
  */
-func UploadOutput(ctx context.Context, filename string, data interface{}) error {
-	// Transform to byte
-	transformed, err := json.Marshal(data)
-	if err != nil {
-		return err
+func (s *Sink) Write(ctx context.Context, artifact sink.Artifact) (string, error) {
+	if s.Client == nil {
+		return "", errors.New("Setting the AWS S3 client is mandatory")
 	}
-	// Create io reader
-	reader := bytes.NewBuffer(transformed)
-
 	// Set input parameter
+	key := path.Join(s.Directory, artifact.Name)
 	input := &s3.PutObjectInput{
-		Bucket:        aws.String(bucketName),
-		Key:           aws.String(path.Join(directoryName, filename)),
-		Body:          reader,
-		ContentLength: int64(len(transformed)),
+		Bucket:        aws.String(s.Bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(artifact.Body),
+		ContentLength: int64(len(artifact.Body)),
+		Metadata:      artifact.Metadata,
+	}
+	if artifact.ContentType != "" {
+		input.ContentType = aws.String(artifact.ContentType)
 	}
 	// Put object
-	_, err = svc.PutObject(ctx, input, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware))
-	return err
+	if _, err := s.Client.PutObject(ctx, input, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware)); err != nil {
+		return "", err
+	}
+	return "s3://" + path.Join(s.Bucket, key), nil
 }
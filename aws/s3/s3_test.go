@@ -0,0 +1,56 @@
+package s3
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	// Output sink
+	"aws-price-scanner/sink"
+)
+
+// Client that records the uploaded objects
+type recordingClient struct {
+	keys   []string
+	bodies []string
+}
+
+func (rc *recordingClient) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := ioutil.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	rc.keys = append(rc.keys, aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key))
+	rc.bodies = append(rc.bodies, string(body))
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestSinkWrite(t *testing.T) {
+	client := &recordingClient{}
+	output, err := NewSink(client, "prices", "catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, err := output.Write(context.Background(), sink.Artifact{Name: "AmazonEC2.json", ContentType: sink.CONTENT_TYPE_JSON, Body: []byte("{}")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location != "s3://prices/catalog/AmazonEC2.json" {
+		t.Errorf("location %s, want s3://prices/catalog/AmazonEC2.json", location)
+	}
+	if len(client.keys) != 1 || client.keys[0] != "prices/catalog/AmazonEC2.json" || client.bodies[0] != "{}" {
+		t.Errorf("uploaded %v %v, want one object", client.keys, client.bodies)
+	}
+}
+
+func TestSinkWithoutClient(t *testing.T) {
+	if _, err := NewSink(nil, "prices", ""); err == nil {
+		t.Error("expected error of missing client")
+	}
+	if _, err := (&Sink{Bucket: "prices"}).Write(context.Background(), sink.Artifact{Name: "a.json"}); err == nil {
+		t.Error("expected error of missing client")
+	}
+}
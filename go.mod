@@ -7,4 +7,5 @@ require (
 	github.com/aws/aws-sdk-go-v2/config v1.10.1
 	github.com/aws/aws-sdk-go-v2/service/pricing v1.9.0
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.19.1
+	gopkg.in/yaml.v2 v2.4.0
 )
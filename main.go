@@ -8,19 +8,29 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	// Custom aws module
+	"aws-price-scanner/aws/bulk"
 	"aws-price-scanner/aws/pricing"
 	"aws-price-scanner/aws/s3"
 
 	// Model
 	"aws-price-scanner/model"
+	// Process
+	"aws-price-scanner/process"
+	// Output sink
+	"aws-price-scanner/sink"
 )
 
 const (
 	ENV_ServiceKey   = "serviceCode"
 	ENV_BucketKey    = "bucket"
 	ENV_DirectoryKey = "directory"
+	ENV_OutKey       = "out"
+	ENV_SourceKey    = "source"
+	ENV_StateKey     = "state"
 )
 
 var testServiceCode = "AmazonECS"
@@ -30,14 +40,18 @@ func init() {
 	if err := pricing.Configure(context.TODO()); err != nil {
 		log.Fatal(err)
 	}
-	// Configure an AWS S3
-	if err := s3.Configure(context.TODO()); err != nil {
-		log.Fatal(err)
-	}
 }
 
 func main() {
-	ctx := context.TODO()
+	// Cancel the scan on interrupt (Ctrl+C, SIGTERM from lambda runtime)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+	}()
 
 	// Create flag to use command line
 	if err := command(); err != nil {
@@ -57,27 +71,44 @@ func main() {
 				}
 			}
 		} else if serviceCode == "all" {
-			// Set s3
-			if err := s3.SetPath(os.Getenv(ENV_BucketKey), os.Getenv(ENV_DirectoryKey)); err != nil {
+			// Set output
+			output, code, err := newSink()
+			if err != nil {
 				fmt.Println(err.Error())
-				os.Exit(101)
+				os.Exit(code)
 			}
-			// Upload list
-			if err := s3.UploadOutput(ctx, "serviceList.json", model.AWS_SERVICE_CODE_LIST); err != nil {
+			// Write list
+			if artifact, err := sink.NewJSONArtifact("serviceList.json", process.ServiceCodes(), nil); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(102)
+			} else if _, err := output.Write(ctx, artifact); err != nil {
 				fmt.Println(err.Error())
 				os.Exit(102)
 			} else {
 				fmt.Println("Processed")
 			}
 		} else {
-			// Set s3
-			if err := s3.SetPath(os.Getenv(ENV_BucketKey), os.Getenv(ENV_DirectoryKey)); err != nil {
+			// Set output
+			output, code, err := newSink()
+			if err != nil {
 				fmt.Println(err.Error())
-				os.Exit(101)
+				os.Exit(code)
+			}
+			// Set source
+			source, err := newSource()
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(model.CODE_ERROR_INVAILD_ARGUMENT)
 			}
 			// Process
-			srv := pricing.NewService(ctx, serviceCode)
-			srv.GetPriceList()
+			def, _ := process.Lookup(serviceCode)
+			fmt.Println("Processing...")
+			result, err := process.OperatePriceCommand(ctx, source, def, output)
+			if err != nil {
+				fmt.Println("[ERROR] " + err.Error())
+				os.Exit(exitCode(err))
+			}
+			fmt.Printf("Process completed (pages: %d, products: %d, merged: %d, skipped: %d, collisions: %d, output: %s, duration: %s)\n", result.Pages, result.Products, result.Merged, result.Skipped, result.Collisions, result.OutputKey, result.Duration)
 		}
 	}
 
@@ -86,13 +117,84 @@ func main() {
 	// Test2(ctx)
 }
 
+/*
+ * Create source of products from process environment (AWS pricing API if no offer file path is set)
+ * @response	{process.Source} source of products
+ * @response	{error} error object (contain nil)
+ */
+func newSource() (process.Source, error) {
+	if path := os.Getenv(ENV_SourceKey); path != "" {
+		source, err := bulk.NewSource(path)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	}
+	return pricing.Source{
+		StateDir: os.Getenv(ENV_StateKey),
+		OnResume: func(def process.ServiceDefinition, pages int) {
+			fmt.Printf("Resume %s from checkpoint (pages: %d)\n", def.Code, pages)
+		},
+	}, nil
+}
+
+/*
+ * Create output sink from process environment
+ * @response	{sink.Sink} output destination
+ * @response	{int} exit code of the sink that failed (CODE_ERROR_INVALID_LOCAL, CODE_ERROR_INVALID_S3 or CODE_ERROR_INVAILD_ARGUMENT)
+ * @response	{error} error object (contain nil)
+ */
+func newSink() (sink.Sink, int, error) {
+	var sinks sink.Multi
+	// Local directory
+	if out := os.Getenv(ENV_OutKey); out != "" {
+		local, err := sink.NewLocal(out)
+		if err != nil {
+			return nil, model.CODE_ERROR_INVALID_LOCAL, err
+		}
+		sinks = append(sinks, local)
+	}
+	// AWS S3 (configure only if a bucket is set, so local mode does not need S3 credentials)
+	if bucket := os.Getenv(ENV_BucketKey); bucket != "" {
+		client, err := s3.Configure(context.TODO())
+		if err != nil {
+			return nil, model.CODE_ERROR_INVALID_S3, err
+		}
+		output, err := s3.NewSink(client, bucket, os.Getenv(ENV_DirectoryKey))
+		if err != nil {
+			return nil, model.CODE_ERROR_INVALID_S3, err
+		}
+		sinks = append(sinks, output)
+	}
+
+	if len(sinks) == 0 {
+		return nil, model.CODE_ERROR_INVAILD_ARGUMENT, errors.New("Storage paths for storing results are essential.")
+	} else if len(sinks) == 1 {
+		return sinks[0], model.CODE_SUCCES, nil
+	}
+	return sinks, model.CODE_SUCCES, nil
+}
+
+/*
+ * Map a scan error to the process exit code
+ * @param			err {error} error object
+ * @response	{int} exit code
+ */
+func exitCode(err error) int {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return model.CODE_ERROR_CANCELED
+	}
+	return model.CODE_ERROR_PROCESS_FAIL
+}
+
 func command() error {
 	// Create usage Description
 	var desc bytes.Buffer
-	desc.WriteString("AWS service code\nSupport a list of service code: ")
-	for i, code := range model.AWS_SERVICE_CODE_LIST {
+	desc.WriteString("AWS service code\nSupport a list of service code: all, ")
+	codes := process.ServiceCodes()
+	for i, code := range codes {
 		desc.WriteString(code)
-		if i < len(model.AWS_SERVICE_CODE_LIST)-1 {
+		if i < len(codes)-1 {
 			desc.WriteString(", ")
 		}
 	}
@@ -100,8 +202,19 @@ func command() error {
 	srvFlag := flag.String("srv", "", desc.String())
 	bucketFlag := flag.String("bucket", "", "AWS S3 bucket name to store output")
 	directoryFlag := flag.String("directory", "", "Directory path in AWS S3 bucket")
+	outFlag := flag.String("out", "", "Local directory to store output (no AWS S3 bucket required)")
+	sourceFlag := flag.String("source", "", "AWS Bulk Price List offer files to read instead of AWS pricing API (directory with index.json, or an offer file)")
+	stateFlag := flag.String("state", "", "Local directory to store checkpoint of scan, so that an interrupted scan resumes (AWS pricing API only)")
+	rulesFlag := flag.String("rules", "", "Rules file or directory of rules files (*.yaml, *.yml, *.json) for service definitions")
 	flag.Parse()
 
+	// Load service definitions from rules files
+	if *rulesFlag != "" {
+		if _, err := process.RegisterRules(*rulesFlag); err != nil {
+			return err
+		}
+	}
+
 	// Check flag
 	if flag.NFlag() == 0 {
 		os.Setenv(ENV_ServiceKey, "")
@@ -111,12 +224,7 @@ func command() error {
 			if *srvFlag == "all" {
 				match = true
 			} else {
-				for _, code := range model.AWS_SERVICE_CODE_LIST {
-					if code == *srvFlag {
-						match = true
-						break
-					}
-				}
+				_, match = process.Lookup(*srvFlag)
 			}
 
 			// Return
@@ -131,9 +239,21 @@ func command() error {
 			os.Setenv(ENV_DirectoryKey, *directoryFlag)
 		}
 
+		if *outFlag != "" {
+			os.Setenv(ENV_OutKey, *outFlag)
+		}
+
+		if *sourceFlag != "" {
+			os.Setenv(ENV_SourceKey, *sourceFlag)
+		}
+
+		if *stateFlag != "" {
+			os.Setenv(ENV_StateKey, *stateFlag)
+		}
+
 		if *bucketFlag != "" {
 			os.Setenv(ENV_BucketKey, *bucketFlag)
-		} else {
+		} else if *outFlag == "" {
 			return errors.New("Storage paths for storing results are essential.")
 		}
 	}
@@ -0,0 +1,175 @@
+package model
+
+// CATALOG_FORMAT_VERSION is the version of the published catalog format (see docs/catalog-format.md).
+// Bump the major version when a field is removed or its meaning changes, the minor version when a field is added.
+const CATALOG_FORMAT_VERSION = "2.0"
+
+// REGION_GLOBAL is the region key of prices that are not specific to a region (e.g. Route 53 hosted zones)
+const REGION_GLOBAL = "global"
+
+// Catalog is the published price catalog of a service ("<serviceCode>.json")
+type Catalog struct {
+	FormatVersion string            `json:"formatVersion"`
+	ServiceCode   string            `json:"serviceCode"`
+	Regions       map[string]Region `json:"regions"`
+	Edges         map[string]Region `json:"edges,omitempty"`
+	// Data transfer matrix (from location -> to location)
+	Transfers map[string]map[string]Transfer `json:"transfers,omitempty"`
+}
+
+// Region groups the product types of a region (or an edge geography) by product type key (e.g. "instance", "storage")
+type Region map[string]ProductType
+
+// ProductType groups the offers of a product type by service type key (e.g. "m5.large", "gp3")
+type ProductType map[string]*Offer
+
+// Transfer groups the data transfer offers of a route by transfer type key (e.g. "interRegionOut", "internetOut")
+type Transfer map[string]*Offer
+
+// Offer is the price information of a service type
+type Offer struct {
+	Sku      string                      `json:"sku"`
+	Product  map[string]string           `json:"product,omitempty"`
+	OnDemand map[string][]PriceDimension `json:"onDemand"`
+	Reserved map[string][]ReservedTerm   `json:"reserved,omitempty"`
+	// Sku of the price of each key (to resolve collisions)
+	onDemandSkus map[string]string
+	reservedSkus map[string]string
+}
+
+// PriceDimension is a price of an offer (a tier of it, if the price is tiered).
+// Price and Range are parsed from the original strings PricePerUnit, BeginRange and EndRange.
+type PriceDimension struct {
+	Description  string            `json:"description"`
+	Unit         string            `json:"unit"`
+	Price        []Money           `json:"price"`
+	Range        *Range            `json:"range,omitempty"`
+	BeginRange   string            `json:"beginRange,omitempty"`
+	EndRange     string            `json:"endRange,omitempty"`
+	PricePerUnit map[string]string `json:"pricePerUnit"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// ReservedTerm is a reservation (Reserved Instance, reserved capacity) price of an offer
+type ReservedTerm struct {
+	LeaseContractLength string            `json:"leaseContractLength"`
+	PurchaseOption      string            `json:"purchaseOption"`
+	OfferingClass       string            `json:"offeringClass,omitempty"`
+	Upfront             []Money           `json:"upfront,omitempty"`
+	Recurring           []Money           `json:"recurring,omitempty"`
+	UpfrontFee          map[string]string `json:"upfrontFee,omitempty"`
+	RecurringFee        map[string]string `json:"recurringFee,omitempty"`
+	RecurringUnit       string            `json:"recurringUnit,omitempty"`
+	Attributes          map[string]string `json:"attributes,omitempty"`
+}
+
+/*
+ * New empty catalog for service
+ * @param			serviceCode {string} service code
+ * @response	{*Catalog} catalog
+ */
+func NewCatalog(serviceCode string) *Catalog {
+	return &Catalog{
+		FormatVersion: CATALOG_FORMAT_VERSION,
+		ServiceCode:   serviceCode,
+		Regions:       make(map[string]Region),
+	}
+}
+
+/*
+ * [Method] Merge processed data into catalog (empty prices are ignored)
+ * When products of different sku collide (same service type or same price key), the lowest sku wins, so the catalog does not depend on the merge order.
+ * @param			data {ProcessedData} processed data
+ * @response	{int} number of price keys that collided with a product of another sku
+ */
+func (c *Catalog) Merge(data ProcessedData) int {
+	// Data transfer route
+	if data.Transfer != nil {
+		return c.mergeTransfer(data)
+	}
+	// Group by edge geography if it is set, otherwise by region (global if the product has no region)
+	groups, key := c.Regions, data.Region
+	if key == "" {
+		key = REGION_GLOBAL
+	}
+	if data.Edge != "" {
+		if c.Edges == nil {
+			c.Edges = make(map[string]Region)
+		}
+		groups, key = c.Edges, data.Edge
+	}
+	region, ok := groups[key]
+	if !ok {
+		region = make(Region)
+		groups[key] = region
+	}
+	productType, ok := region[data.ProductType]
+	if !ok {
+		productType = make(ProductType)
+		region[data.ProductType] = productType
+	}
+	return mergeOffer(productType, data)
+}
+
+func (c *Catalog) mergeTransfer(data ProcessedData) int {
+	if c.Transfers == nil {
+		c.Transfers = make(map[string]map[string]Transfer)
+	}
+	from, ok := c.Transfers[data.Transfer.From]
+	if !ok {
+		from = make(map[string]Transfer)
+		c.Transfers[data.Transfer.From] = from
+	}
+	transfer, ok := from[data.Transfer.To]
+	if !ok {
+		transfer = make(Transfer)
+		from[data.Transfer.To] = transfer
+	}
+	return mergeOffer(transfer, data)
+}
+
+func mergeOffer(offers map[string]*Offer, data ProcessedData) int {
+	offer, ok := offers[data.ServiceType]
+	if !ok {
+		offer = &Offer{
+			Sku:          data.Sku,
+			Product:      data.Product,
+			OnDemand:     make(map[string][]PriceDimension),
+			onDemandSkus: make(map[string]string),
+			reservedSkus: make(map[string]string),
+		}
+		offers[data.ServiceType] = offer
+	} else if data.Sku < offer.Sku {
+		offer.Sku = data.Sku
+		offer.Product = data.Product
+	}
+	collisions := 0
+	for key, value := range data.OnDemand {
+		if len(value) == 0 {
+			continue
+		} else if sku, ok := offer.onDemandSkus[key]; ok && sku != data.Sku {
+			collisions++
+			if sku < data.Sku {
+				continue
+			}
+		}
+		offer.OnDemand[key] = value
+		offer.onDemandSkus[key] = data.Sku
+	}
+	for key, value := range data.Reserved {
+		if len(value) == 0 {
+			continue
+		} else if sku, ok := offer.reservedSkus[key]; ok && sku != data.Sku {
+			collisions++
+			if sku < data.Sku {
+				continue
+			}
+		}
+		if offer.Reserved == nil {
+			offer.Reserved = make(map[string][]ReservedTerm)
+		}
+		offer.Reserved[key] = value
+		offer.reservedSkus[key] = data.Sku
+	}
+	return collisions
+}
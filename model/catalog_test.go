@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func newTestData(sku string, serviceType string, key string, amount string) ProcessedData {
+	return ProcessedData{
+		OnDemand: map[string][]PriceDimension{
+			key: {{Unit: "Hrs", PricePerUnit: map[string]string{"USD": amount}}},
+		},
+		Product:     map[string]string{"sku": sku},
+		ProductType: "instance",
+		Region:      "us-east-1",
+		Reserved: map[string][]ReservedTerm{
+			key: {{LeaseContractLength: "1yr", RecurringFee: map[string]string{"USD": amount}}},
+		},
+		ServiceType: serviceType,
+		Sku:         sku,
+	}
+}
+
+func TestCatalogMergeIsOrderIndependent(t *testing.T) {
+	items := []ProcessedData{
+		newTestData("CCC", "m5.large", "RunInstances", "3"),
+		newTestData("AAA", "m5.large", "RunInstances", "1"),
+		newTestData("BBB", "m5.large", "RunInstances:Multi", "2"),
+		newTestData("DDD", "m5.large", "RunInstances:Multi", "4"),
+	}
+	orders := [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {1, 3, 0, 2}, {2, 0, 3, 1}}
+
+	var expected string
+	for _, order := range orders {
+		catalog := NewCatalog("Test")
+		collisions := 0
+		for _, i := range order {
+			collisions += catalog.Merge(items[i])
+		}
+		// Two keys collide, for on-demand and reserved prices
+		if collisions != 4 {
+			t.Errorf("order %v: collisions %d, want 4", order, collisions)
+		}
+		content, err := json.Marshal(catalog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected == "" {
+			expected = string(content)
+		} else if string(content) != expected {
+			t.Errorf("order %v: catalog differs\n got: %s\nwant: %s", order, content, expected)
+		}
+	}
+
+	// The lowest sku wins
+	catalog := NewCatalog("Test")
+	for _, i := range orders[1] {
+		catalog.Merge(items[i])
+	}
+	offer := catalog.Regions["us-east-1"]["instance"]["m5.large"]
+	if offer.Sku != "AAA" || offer.Product["sku"] != "AAA" {
+		t.Errorf("sku %q, product %v, want AAA", offer.Sku, offer.Product)
+	}
+	if amount := offer.OnDemand["RunInstances"][0].PricePerUnit["USD"]; amount != "1" {
+		t.Errorf("RunInstances price %q, want 1", amount)
+	}
+	if amount := offer.OnDemand["RunInstances:Multi"][0].PricePerUnit["USD"]; amount != "2" {
+		t.Errorf("RunInstances:Multi price %q, want 2", amount)
+	}
+	if amount := offer.Reserved["RunInstances"][0].RecurringFee["USD"]; amount != "1" {
+		t.Errorf("RunInstances reserved price %q, want 1", amount)
+	}
+}
+
+func TestCatalogMergeSameSkuIsNoCollision(t *testing.T) {
+	catalog := NewCatalog("Test")
+	data := newTestData("AAA", "m5.large", "RunInstances", "1")
+	if n := catalog.Merge(data) + catalog.Merge(data); n != 0 {
+		t.Errorf("collisions %d, want 0", n)
+	}
+}
@@ -1,30 +1,103 @@
 package model
 
+import (
+	"strings"
+	"time"
+)
+
 const (
-	AWS_SERVICE_CODE_DYNAMODB = "AmazonDynamoDB"
-	AWS_SERVICE_CODE_EBS      = "AmazonEBS"
-	AWS_SERVICE_CODE_EC2      = "AmazonEC2"
-	AWS_SERVICE_CODE_ECS      = "AmazonECS"
-	AWS_SERVICE_CODE_EFS      = "AmazonEFS"
-	AWS_SERVICE_CODE_ELB      = "AWSELB"
-	AWS_SERVICE_CODE_LAMBDA   = "AWSLambda"
-	AWS_SERVICE_CODE_RDS      = "AmazonRDS"
-	AWS_SERVICE_CODE_S3       = "AmazonS3"
-	AWS_SERVICE_CODE_VPC      = "AmazonVPC"
+	AWS_SERVICE_CODE_APIGATEWAY     = "AmazonApiGateway"
+	AWS_SERVICE_CODE_CLOUDFRONT     = "AmazonCloudFront"
+	AWS_SERVICE_CODE_CLOUDWATCH     = "AmazonCloudWatch"
+	AWS_SERVICE_CODE_DATATRANSFER   = "AWSDataTransfer"
+	AWS_SERVICE_CODE_DYNAMODB       = "AmazonDynamoDB"
+	AWS_SERVICE_CODE_EBS            = "AmazonEBS"
+	AWS_SERVICE_CODE_EC2            = "AmazonEC2"
+	AWS_SERVICE_CODE_ECR            = "AmazonECR"
+	AWS_SERVICE_CODE_ECS            = "AmazonECS"
+	AWS_SERVICE_CODE_EFS            = "AmazonEFS"
+	AWS_SERVICE_CODE_EKS            = "AmazonEKS"
+	AWS_SERVICE_CODE_ELASTICACHE    = "AmazonElastiCache"
+	AWS_SERVICE_CODE_ELB            = "AWSELB"
+	AWS_SERVICE_CODE_FIREHOSE       = "AmazonKinesisFirehose"
+	AWS_SERVICE_CODE_KINESIS        = "AmazonKinesis"
+	AWS_SERVICE_CODE_KMS            = "awskms"
+	AWS_SERVICE_CODE_LAMBDA         = "AWSLambda"
+	AWS_SERVICE_CODE_OPENSEARCH     = "AmazonES"
+	AWS_SERVICE_CODE_RDS            = "AmazonRDS"
+	AWS_SERVICE_CODE_REDSHIFT       = "AmazonRedshift"
+	AWS_SERVICE_CODE_ROUTE53        = "AmazonRoute53"
+	AWS_SERVICE_CODE_S3             = "AmazonS3"
+	AWS_SERVICE_CODE_SECRETSMANAGER = "AWSSecretsManager"
+	AWS_SERVICE_CODE_SNS            = "AmazonSNS"
+	AWS_SERVICE_CODE_SQS            = "AWSQueueService"
+	AWS_SERVICE_CODE_STEPFUNCTIONS  = "AWSStepFunctions"
+	AWS_SERVICE_CODE_VPC            = "AmazonVPC"
+	AWS_SERVICE_CODE_WAF            = "awswaf"
 
 	CODE_SUCCES                 = 0
 	CODE_ERROR_INVAILD_ARGUMENT = 100
 	CODE_ERROR_INVALID_S3       = 101
+	CODE_ERROR_INVALID_LOCAL    = 103
 	CODE_ERROR_PROCESS_FAIL     = 104
+	CODE_ERROR_CANCELED         = 105
 )
 
-var AWS_SERVICE_CODE_LIST = []string{"AmazonDynamoDB", "AmazonEBS", "AmazonEC2", "AmazonECS", "AmazonEFS", "AWSELB", "AWSLambda", "AmazonRDS", "AmazonS3", "AmazonVPC"}
+type Filter struct {
+	Field string `json:"field" yaml:"field"`
+	Value string `json:"value" yaml:"value"`
+}
+
+/*
+ * [Method] Check whether product matches filter (same as TERM_MATCH of AWS pricing API, case-insensitive)
+ * @param			rawData {RawData} product
+ * @response	{bool} whether product matches
+ */
+func (f Filter) Match(rawData RawData) bool {
+	switch strings.ToLower(f.Field) {
+	case "servicecode":
+		return true
+	case "sku":
+		return strings.EqualFold(rawData.Product.Sku, f.Value)
+	case "productfamily":
+		return strings.EqualFold(rawData.Product.ProductFamily, f.Value)
+	case "termtype":
+		if strings.EqualFold(f.Value, "OnDemand") {
+			return len(rawData.Terms.OnDemand) > 0
+		} else if strings.EqualFold(f.Value, "Reserved") {
+			return len(rawData.Terms.Reserved) > 0
+		}
+		return false
+	}
+	// Product attribute (attribute name is case-insensitive)
+	if value, ok := rawData.Product.Attributes[f.Field]; ok {
+		return strings.EqualFold(value, f.Value)
+	}
+	for key, value := range rawData.Product.Attributes {
+		if strings.EqualFold(key, f.Field) {
+			return strings.EqualFold(value, f.Value)
+		}
+	}
+	return false
+}
 
 type ProcessResult struct {
+	Error   error  `json:"-"`
 	Result  bool   `json:"result"`
 	Message string `json:"message"`
 }
 
+type ScanResult struct {
+	ServiceCode string        `json:"serviceCode"`
+	Pages       int           `json:"pages"`
+	Products    int           `json:"products"`
+	Merged      int           `json:"merged"`
+	Skipped     int           `json:"skipped"`
+	Collisions  int           `json:"collisions"`
+	OutputKey   string        `json:"outputKey"`
+	Duration    time.Duration `json:"duration"`
+}
+
 type RawData struct {
 	Product struct {
 		ProductFamily string            `json:"productFamily,omitempty"`
@@ -32,17 +105,45 @@ type RawData struct {
 		Sku           string            `json:"sku"`
 	} `json:"product"`
 	Terms struct {
-		OnDemand interface{} `json:"OnDemand"`
+		OnDemand map[string]RawTerm `json:"OnDemand"`
+		Reserved map[string]RawTerm `json:"Reserved"`
 	} `json:"terms"`
 	Version string `json:"version"`
 }
 
+type RawTerm struct {
+	EffectiveDate   string                       `json:"effectiveDate"`
+	OfferTermCode   string                       `json:"offerTermCode"`
+	PriceDimensions map[string]RawPriceDimension `json:"priceDimensions"`
+	Sku             string                       `json:"sku"`
+	TermAttributes  map[string]string            `json:"termAttributes"`
+}
+
+type RawPriceDimension struct {
+	AppliesTo    []string          `json:"appliesTo"`
+	BeginRange   string            `json:"beginRange"`
+	Description  string            `json:"description"`
+	EndRange     string            `json:"endRange"`
+	PricePerUnit map[string]string `json:"pricePerUnit"`
+	RateCode     string            `json:"rateCode"`
+	Unit         string            `json:"unit"`
+}
+
 type ProcessedData struct {
-	OnDemand    map[string][]map[string]interface{} `json:"onDemand"`
-	Product     map[string]string                   `json:"product"`
-	ProductType string                              `json:"productType"`
-	Region      string                              `json:"region,omitempty"`
-	ServiceType string                              `json:"serviceType"`
-	Sku         string                              `json:"sku"`
-	UsageType   string                              `json:"usageType"`
+	Edge        string                      `json:"edge,omitempty"`
+	OnDemand    map[string][]PriceDimension `json:"onDemand"`
+	Product     map[string]string           `json:"product"`
+	ProductType string                      `json:"productType"`
+	Region      string                      `json:"region,omitempty"`
+	Reserved    map[string][]ReservedTerm   `json:"reserved,omitempty"`
+	ServiceType string                      `json:"serviceType"`
+	Sku         string                      `json:"sku"`
+	Transfer    *TransferRoute              `json:"transfer,omitempty"`
+	UsageType   string                      `json:"usageType"`
+}
+
+// TransferRoute is the route of a data transfer price (the data is merged into the data transfer matrix instead of a region)
+type TransferRoute struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
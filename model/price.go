@@ -0,0 +1,161 @@
+package model
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MAX_DECIMAL_EXPONENT bounds the exponent of a decimal string (prices are far from it, a huge exponent takes long to expand)
+const MAX_DECIMAL_EXPONENT = 100
+
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// Decimal is an exact decimal number in canonical form (no exponent, no trailing zeros, e.g. "0.0000166667")
+type Decimal string
+
+// Money is an exact amount of a currency
+type Money struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// Range is the numeric range of a price tier (End is empty when the range is unbounded)
+type Range struct {
+	Begin     Decimal `json:"begin"`
+	End       Decimal `json:"end,omitempty"`
+	Unbounded bool    `json:"unbounded,omitempty"`
+}
+
+/*
+ * Parse decimal string (e.g. "0.1180000000", "1.5E-4") without loss of precision
+ * @param			s {string} decimal string
+ * @response	{Decimal} canonical decimal
+ * @response	{error} error object (contain nil)
+ */
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	match := decimalPattern.FindStringSubmatch(s)
+	if match == nil {
+		return "", fmt.Errorf("Invalid decimal %q", s)
+	}
+	if match[2] != "" {
+		if exp, err := strconv.Atoi(match[2][1:]); err != nil || exp > MAX_DECIMAL_EXPONENT || exp < -MAX_DECIMAL_EXPONENT {
+			return "", fmt.Errorf("Exponent of decimal %q is out of range", s)
+		}
+	}
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", fmt.Errorf("Invalid decimal %q", s)
+	}
+	return decimalFromRat(rat), nil
+}
+
+func decimalFromRat(rat *big.Rat) Decimal {
+	// Find the number of fraction digits (denominator is 2^a * 5^b for a decimal)
+	denom := new(big.Int).Set(rat.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	mod := new(big.Int)
+	a, b := 0, 0
+	for mod.Mod(denom, two).Sign() == 0 {
+		denom.Quo(denom, two)
+		a++
+	}
+	for mod.Mod(denom, five).Sign() == 0 {
+		denom.Quo(denom, five)
+		b++
+	}
+	prec := a
+	if b > prec {
+		prec = b
+	}
+	str := rat.FloatString(prec)
+	if strings.Contains(str, ".") {
+		str = strings.TrimRight(strings.TrimRight(str, "0"), ".")
+	}
+	if str == "-0" {
+		str = "0"
+	}
+	return Decimal(str)
+}
+
+/*
+ * [Method] Get exact rational number of decimal
+ * @response	{*big.Rat} rational number (nil if decimal is empty or invalid)
+ */
+func (d Decimal) Rat() *big.Rat {
+	if d == "" {
+		return nil
+	}
+	rat, ok := new(big.Rat).SetString(string(d))
+	if !ok {
+		return nil
+	}
+	return rat
+}
+
+/*
+ * [Method] Compare decimals
+ * @param			other {Decimal} decimal to compare
+ * @response	{int} -1 if d < other, 0 if d == other, +1 if d > other (empty decimal is the smallest)
+ */
+func (d Decimal) Cmp(other Decimal) int {
+	x, y := d.Rat(), other.Rat()
+	if x == nil || y == nil {
+		if x == nil && y == nil {
+			return 0
+		} else if x == nil {
+			return -1
+		}
+		return 1
+	}
+	return x.Cmp(y)
+}
+
+/*
+ * Parse price per unit (e.g. {"USD": "0.1180000000"}) into a list of money sorted by currency
+ * @param			pricePerUnit {map[string]string} price per currency
+ * @response	{[]Money} a list of money (invalid prices are omitted)
+ */
+func ParsePrices(pricePerUnit map[string]string) []Money {
+	result := make([]Money, 0, len(pricePerUnit))
+	for currency, value := range pricePerUnit {
+		if amount, err := ParseDecimal(value); err == nil {
+			result = append(result, Money{Amount: amount, Currency: currency})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
+
+/*
+ * Parse price tier range (end "Inf" is unbounded)
+ * @param			begin {string} begin of range
+ * @param			end {string} end of range
+ * @response	{*Range} range (nil if begin and end are empty or invalid)
+ */
+func ParseRange(begin string, end string) *Range {
+	if begin == "" && end == "" {
+		return nil
+	}
+	result := &Range{}
+	var err error
+	if begin != "" {
+		if result.Begin, err = ParseDecimal(begin); err != nil {
+			return nil
+		}
+	} else {
+		result.Begin = "0"
+	}
+	if end == "" || strings.EqualFold(end, "Inf") || strings.EqualFold(end, "Infinity") {
+		result.Unbounded = true
+	} else if result.End, err = ParseDecimal(end); err != nil {
+		return nil
+	}
+	return result
+}
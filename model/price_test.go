@@ -0,0 +1,117 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Decimal
+		valid    bool
+	}{
+		{"0.1180000000", "0.118", true},
+		{"0.0000000000", "0", true},
+		{"1.0", "1", true},
+		{"100", "100", true},
+		{"100.", "100", true},
+		{".5", "0.5", true},
+		{"+2.50", "2.5", true},
+		{"-0.0", "0", true},
+		{"-1.25", "-1.25", true},
+		{" 0.01 ", "0.01", true},
+		{"0.0000166667", "0.0000166667", true},
+		{"1.5E-4", "0.00015", true},
+		{"1.5e-4", "0.00015", true},
+		{"2E+3", "2000", true},
+		{"2.50E1", "25", true},
+		{"1e-20", "0.00000000000000000001", true},
+		{"1e100", Decimal("1" + strings.Repeat("0", 100)), true},
+		{"1e-100", Decimal("0." + strings.Repeat("0", 99) + "1"), true},
+		{"1e101", "", false},
+		{"1e-1000000", "", false},
+		{"Inf", "", false},
+		{"-Infinity", "", false},
+		{"NaN", "", false},
+		{"", "", false},
+		{".", "", false},
+		{"1e", "", false},
+		{"1,000", "", false},
+		{"0x10", "", false},
+		{"1/3", "", false},
+		{"USD 1", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := ParseDecimal(test.input)
+			if !test.valid {
+				if err == nil {
+					t.Errorf("result %q, want error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != test.expected {
+				t.Errorf("result %q, want %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		begin    string
+		end      string
+		expected *Range
+	}{
+		{"bounded", "0", "10240.0000000000", &Range{Begin: "0", End: "10240"}},
+		{"unbounded", "51200", "Inf", &Range{Begin: "51200", Unbounded: true}},
+		{"unbounded infinity", "1.5E3", "infinity", &Range{Begin: "1500", Unbounded: true}},
+		{"no end", "10", "", &Range{Begin: "10", Unbounded: true}},
+		{"no begin", "", "100", &Range{Begin: "0", End: "100"}},
+		{"empty", "", "", nil},
+		{"invalid begin", "zero", "100", nil},
+		{"invalid end", "0", "100 GB", nil},
+		{"infinite begin", "Inf", "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := ParseRange(test.begin, test.end); !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("result %+v, want %+v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParsePrices(t *testing.T) {
+	result := ParsePrices(map[string]string{"USD": "0.1180000000", "CNY": "0.80", "EUR": "invalid"})
+	expected := []Money{{Amount: "0.8", Currency: "CNY"}, {Amount: "0.118", Currency: "USD"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("result %+v, want %+v", result, expected)
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		x        Decimal
+		y        Decimal
+		expected int
+	}{
+		{"0.118", "0.12", -1},
+		{"10", "9.99", 1},
+		{"1", "1", 0},
+		{"", "0", -1},
+		{"0", "", 1},
+		{"", "", 0},
+	}
+	for _, test := range tests {
+		if result := test.x.Cmp(test.y); result != test.expected {
+			t.Errorf("%q.Cmp(%q) = %d, want %d", test.x, test.y, result, test.expected)
+		}
+	}
+}
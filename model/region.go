@@ -0,0 +1,54 @@
+package model
+
+// REGION_CODES maps the location name of AWS pricing API to the region code
+var REGION_CODES = map[string]string{
+	"Africa (Cape Town)":        "af-south-1",
+	"Asia Pacific (Hong Kong)":  "ap-east-1",
+	"Asia Pacific (Hyderabad)":  "ap-south-2",
+	"Asia Pacific (Jakarta)":    "ap-southeast-3",
+	"Asia Pacific (Malaysia)":   "ap-southeast-5",
+	"Asia Pacific (Melbourne)":  "ap-southeast-4",
+	"Asia Pacific (Mumbai)":     "ap-south-1",
+	"Asia Pacific (Osaka)":      "ap-northeast-3",
+	"Asia Pacific (Seoul)":      "ap-northeast-2",
+	"Asia Pacific (Singapore)":  "ap-southeast-1",
+	"Asia Pacific (Sydney)":     "ap-southeast-2",
+	"Asia Pacific (Taipei)":     "ap-east-2",
+	"Asia Pacific (Thailand)":   "ap-southeast-7",
+	"Asia Pacific (Tokyo)":      "ap-northeast-1",
+	"AWS GovCloud (US)":         "us-gov-west-1",
+	"AWS GovCloud (US-East)":    "us-gov-east-1",
+	"AWS GovCloud (US-West)":    "us-gov-west-1",
+	"Canada (Central)":          "ca-central-1",
+	"Canada West (Calgary)":     "ca-west-1",
+	"China (Beijing)":           "cn-north-1",
+	"China (Ningxia)":           "cn-northwest-1",
+	"EU (Frankfurt)":            "eu-central-1",
+	"EU (Ireland)":              "eu-west-1",
+	"EU (London)":               "eu-west-2",
+	"EU (Milan)":                "eu-south-1",
+	"EU (Paris)":                "eu-west-3",
+	"EU (Spain)":                "eu-south-2",
+	"EU (Stockholm)":            "eu-north-1",
+	"EU (Zurich)":               "eu-central-2",
+	"Israel (Tel Aviv)":         "il-central-1",
+	"Mexico (Central)":          "mx-central-1",
+	"Middle East (Bahrain)":     "me-south-1",
+	"Middle East (UAE)":         "me-central-1",
+	"South America (Sao Paulo)": "sa-east-1",
+	"US East (N. Virginia)":     "us-east-1",
+	"US East (Ohio)":            "us-east-2",
+	"US West (N. California)":   "us-west-1",
+	"US West (Oregon)":          "us-west-2",
+}
+
+/*
+ * Find region code by location name of AWS pricing API
+ * @param			location {string} location name (e.g. "US East (N. Virginia)")
+ * @response	{string} region code
+ * @response	{bool} whether location is a known region
+ */
+func RegionCode(location string) (string, bool) {
+	code, ok := REGION_CODES[location]
+	return code, ok
+}
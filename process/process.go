@@ -2,281 +2,197 @@ package process
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"runtime"
-
-	// AWS
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/pricing"
-	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"time"
 
 	// Model
 	"aws-price-scanner/model"
-	// Custom S3
-	"aws-price-scanner/aws/s3"
+	// Output sink
+	"aws-price-scanner/sink"
 )
 
-const FORMAT_VERSION = "aws_v1"
-
-func OperatePriceCommandForTest(ctx context.Context, client *pricing.Client, serviceCode string, filters []types.Filter) error {
-	// If service code is EBS
-	tServiceCode := serviceCode
-	if tServiceCode == model.AWS_SERVICE_CODE_EBS {
-		tServiceCode = model.AWS_SERVICE_CODE_EC2
-	}
-	// Set input parameter
-	input := &pricing.GetProductsInput{
-		Filters:       filters,
-		FormatVersion: aws.String(FORMAT_VERSION),
-		MaxResults:    int32(20),
-		ServiceCode:   aws.String(tServiceCode),
-	}
+// Source provides the products of a service (AWS pricing API, bulk offer files, ...)
+type Source interface {
+	// Products calls emit with every page of products matching the service definition
+	Products(ctx context.Context, def ServiceDefinition, emit func(page []model.RawData) error) error
+}
 
-	output, err := client.GetProducts(ctx, input)
-	if err != nil {
-		return err
-	}
+// Completer is implemented by a source that keeps the state of a request until the output of the scan is stored (e.g. checkpoint)
+type Completer interface {
+	// Complete is called for every request of the service definition once the output of the scan is stored
+	Complete(ctx context.Context, def ServiceDefinition) error
+}
 
-	for _, data := range output.PriceList {
-		fmt.Println(data)
-		fmt.Println()
+/*
+ * Get a list of price information for service, transform and merge it, then write output to sink
+ * @param			ctx {context.Context} context (cancellation stops the scan)
+ * @param			source {Source} source of products
+ * @param			def {ServiceDefinition} service definition
+ * @param			output {sink.Sink} output destination
+ * @response	{*model.ScanResult} scan result (contain nil)
+ * @response	{error} error object (contain nil)
+ */
+func OperatePriceCommand(ctx context.Context, source Source, def ServiceDefinition, output sink.Sink) (*model.ScanResult, error) {
+	if def.Transform == nil {
+		return nil, errors.New("Transformer of service definition is mandatory (" + def.Code + ")")
 	}
-	return nil
-}
+	start := time.Now()
+	// Stop every worker when the scan returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-func OperatePriceCommand(ctx context.Context, client *pricing.Client, serviceCode string, filters []types.Filter) {
 	cpuCore := runtime.NumCPU()
 	// Set channel queue (for raw data and processed data)
 	iQueue := make(chan model.RawData, 600)
-	oQueue := make(chan interface{}, 600)
+	oQueue := make(chan model.ProcessedData, 600)
 	// Set channel queue (for process)
-	iProc := make(chan model.ProcessResult, cpuCore)
+	iProc := make(chan model.ProcessResult, 1)
 	oProc := make(chan model.ProcessResult, cpuCore)
 	eProc := make(chan model.ProcessResult, 1)
 
-	// If service code is EBS
-	tServiceCode := serviceCode
-	if tServiceCode == model.AWS_SERVICE_CODE_EBS {
-		tServiceCode = model.AWS_SERVICE_CODE_EC2
-	}
-	// Set input parameter
-	input := &pricing.GetProductsInput{
-		Filters:       filters,
-		FormatVersion: aws.String(FORMAT_VERSION),
-		MaxResults:    int32(100),
-		ServiceCode:   aws.String(tServiceCode),
-	}
-
-	fmt.Println("Configure complete")
-	fmt.Println("Processing...")
-
-	// Execute process (extract and transform data, merge transformed data)
+	// Execute process (request and transform data, merge transformed data)
+	result := &model.ScanResult{ServiceCode: def.Code}
 	for i := 0; i < cpuCore; i++ {
-		go transformPriceData(serviceCode, iQueue, oQueue, oProc)
-	}
-	go mergePriceData(ctx, serviceCode, oQueue, eProc)
-
-	// Create a paginator
-	paginator := pricing.NewGetProductsPaginator(client, input)
-	// Process logic
-	pCnt := 0
-	for {
-		output, err := paginator.NextPage(ctx)
-		if err != nil {
-			fmt.Println("[ERROR] " + err.Error())
-			return
-		}
-		go extractPriceData(output, iQueue, iProc)
-		pCnt++
-		// Escape
-		if !paginator.HasMorePages() {
-			break
-		}
+		go transformPriceData(ctx, def.Transform, iQueue, oQueue, oProc)
 	}
+	go mergePriceData(ctx, def.Code, oQueue, output, result, eProc)
+	go requestPriceData(ctx, source, def, iQueue, result, iProc)
 
 	// Exit logic
-	iCompleted := 0
 	oCompleted := 0
 	for {
 		select {
-		case <-iProc:
-			iCompleted++
-			if iCompleted >= pCnt {
-				close(iQueue)
-				// Print message
-				fmt.Println("Request data complete.")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case proc := <-iProc:
+			if !proc.Result {
+				return nil, fmt.Errorf("request price list for %s (page %d): %w", def.Code, result.Pages+1, proc.Error)
 			}
 		case <-oProc:
 			oCompleted++
-			if oCompleted >= runtime.NumCPU() {
+			if oCompleted >= cpuCore {
 				close(oQueue)
-				// Print message
-				fmt.Println("Transform data completed.")
 			}
-		case result := <-eProc:
-			if result.Result {
-				fmt.Println(result.Message)
-				os.Exit(model.CODE_SUCCES)
-			} else {
-				fmt.Println("[ERROR] " + result.Message)
-				os.Exit(model.CODE_ERROR_PROCESS_FAIL)
+		case proc := <-eProc:
+			if !proc.Result {
+				return nil, fmt.Errorf("store price data for %s: %w", def.Code, proc.Error)
 			}
-			return
+			// Release the state of requests once the output is stored (a failed write resumes from the state)
+			if completer, ok := source.(Completer); ok {
+				for _, request := range def.requests() {
+					if err := completer.Complete(ctx, request); err != nil {
+						return nil, fmt.Errorf("complete request %s: %w", request.Code, err)
+					}
+				}
+			}
+			result.Duration = time.Since(start)
+			return result, nil
 		}
 	}
 }
 
-func extractPriceData(output *pricing.GetProductsOutput, iQueue chan<- model.RawData, iProc chan<- model.ProcessResult) {
-	for _, data := range output.PriceList {
-		// Transform
-		var rawData model.RawData
-		if err := json.Unmarshal([]byte(data), &rawData); err != nil {
-			iProc <- model.ProcessResult{
-				Result:  false,
-				Message: err.Error(),
+func requestPriceData(ctx context.Context, source Source, def ServiceDefinition, iQueue chan<- model.RawData, result *model.ScanResult, iProc chan<- model.ProcessResult) {
+	// Release transform workers on every path
+	defer close(iQueue)
+	// Request products of the service and of its additional queries in order
+	for _, request := range def.requests() {
+		err := source.Products(ctx, request, func(page []model.RawData) error {
+			for _, rawData := range page {
+				// Push data
+				select {
+				case iQueue <- rawData:
+					result.Products++
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			result.Pages++
+			return nil
+		})
+		if err != nil {
+			select {
+			case iProc <- model.ProcessResult{Result: false, Message: err.Error(), Error: err}:
+			case <-ctx.Done():
 			}
+			return
 		}
-		// Push data
-		iQueue <- rawData
 	}
 	// Exit
-	iProc <- model.ProcessResult{Result: true}
+	select {
+	case iProc <- model.ProcessResult{Result: true}:
+	case <-ctx.Done():
+	}
 }
 
-func transformPriceData(serviceCode string, iQueue <-chan model.RawData, oQueue chan<- interface{}, oProc chan<- model.ProcessResult) {
-	for data, ok := <-iQueue; ok; data, ok = <-iQueue {
-		switch serviceCode {
-		case model.AWS_SERVICE_CODE_DYNAMODB:
-			oQueue <- transformPriceDataForDynamoDB(data)
-		case model.AWS_SERVICE_CODE_EBS:
-			oQueue <- transformPriceDataForEBS(data)
-		case model.AWS_SERVICE_CODE_EC2:
-			oQueue <- transformPriceDataForEC2(data)
-		case model.AWS_SERVICE_CODE_ECS:
-			oQueue <- transformPriceDataForECS(data)
-		case model.AWS_SERVICE_CODE_EFS:
-			oQueue <- transformPriceDataForEFS(data)
-		case model.AWS_SERVICE_CODE_ELB:
-			oQueue <- transformPriceDataForELB(data)
-		case model.AWS_SERVICE_CODE_LAMBDA:
-			oQueue <- transformPriceDataForLambda(data)
-		case model.AWS_SERVICE_CODE_RDS:
-			oQueue <- transformPriceDataForRDS(data)
-		case model.AWS_SERVICE_CODE_S3:
-			oQueue <- transformPriceDataForS3(data)
-		case model.AWS_SERVICE_CODE_VPC:
-			oQueue <- transformPriceDataForVPC(data)
+func transformPriceData(ctx context.Context, transform func(model.RawData) model.ProcessedData, iQueue <-chan model.RawData, oQueue chan<- model.ProcessedData, oProc chan<- model.ProcessResult) {
+	for {
+		var data model.RawData
+		var ok bool
+		select {
+		case data, ok = <-iQueue:
+		case <-ctx.Done():
+			return
+		}
+		if !ok {
+			break
+		}
+		// Push data
+		select {
+		case oQueue <- transform(data):
+		case <-ctx.Done():
+			return
 		}
 	}
 	// Exit
-	oProc <- model.ProcessResult{Result: true}
+	select {
+	case oProc <- model.ProcessResult{Result: true}:
+	case <-ctx.Done():
+	}
 }
 
-func mergePriceData(ctx context.Context, serviceCode string, oQueue <-chan interface{}, eProc chan<- model.ProcessResult) {
+func mergePriceData(ctx context.Context, serviceCode string, oQueue <-chan model.ProcessedData, output sink.Sink, result *model.ScanResult, eProc chan<- model.ProcessResult) {
 	// // Set output file name
 	filename := serviceCode + ".json"
 
 	// Merge data
-	output := make(map[string]map[string]map[string]map[string]interface{})
-	for data, ok := <-oQueue; ok; data, ok = <-oQueue {
+	catalog := model.NewCatalog(serviceCode)
+	for {
+		var data model.ProcessedData
+		var ok bool
+		select {
+		case data, ok = <-oQueue:
+		case <-ctx.Done():
+			return
+		}
+		if !ok {
+			break
+		}
 		// Check distinguish key (if it is "none", not processing)
-		productType := data.(model.ProcessedData).ProductType
-		if productType == "none" {
+		if data.ProductType == "none" {
+			result.Skipped++
 			continue
 		}
-
-		// Extract region code and service type
-		region := data.(model.ProcessedData).Region
-		serviceType := data.(model.ProcessedData).ServiceType
-		// Merge
-		if _, ok := output[region]; !ok {
-			output[region] = make(map[string]map[string]map[string]interface{})
-			output[region][productType] = map[string]map[string]interface{}{
-				serviceType: make(map[string]interface{}),
-			}
-			output[region][productType][serviceType] = map[string]interface{}{
-				"onDemand": make(map[string][]map[string]interface{}),
-				"product":  data.(model.ProcessedData).Product,
-				"sku":      data.(model.ProcessedData).Sku,
-			}
-			for key, value := range data.(model.ProcessedData).OnDemand {
-				(output[region][productType][serviceType]["onDemand"]).(map[string][]map[string]interface{})[key] = value
-			}
-		} else if _, ok := output[region][productType]; !ok {
-			output[region][productType] = map[string]map[string]interface{}{
-				serviceType: make(map[string]interface{}),
-			}
-			output[region][productType][serviceType] = map[string]interface{}{
-				"onDemand": make(map[string][]map[string]interface{}),
-				"product":  data.(model.ProcessedData).Product,
-				"sku":      data.(model.ProcessedData).Sku,
-			}
-			for key, value := range data.(model.ProcessedData).OnDemand {
-				(output[region][productType][serviceType]["onDemand"]).(map[string][]map[string]interface{})[key] = value
-			}
-		} else if _, ok := output[region][productType][serviceType]; !ok {
-			output[region][productType][serviceType] = map[string]interface{}{
-				"onDemand": make(map[string][]map[string]interface{}),
-				"product":  data.(model.ProcessedData).Product,
-				"sku":      data.(model.ProcessedData).Sku,
-			}
-			for key, value := range data.(model.ProcessedData).OnDemand {
-				(output[region][productType][serviceType]["onDemand"]).(map[string][]map[string]interface{})[key] = value
-			}
-		} else {
-			for key, value := range data.(model.ProcessedData).OnDemand {
-				(output[region][productType][serviceType]["onDemand"]).(map[string][]map[string]interface{})[key] = value
-			}
-		}
+		result.Collisions += catalog.Merge(data)
+		result.Merged++
 	}
 
-	if err := s3.UploadOutput(ctx, filename, output); err != nil {
-		eProc <- model.ProcessResult{
-			Result:  false,
-			Message: err.Error(),
-		}
+	// Write output
+	proc := model.ProcessResult{Result: true, Message: "Process completed"}
+	if artifact, err := sink.NewJSONArtifact(filename, catalog, map[string]string{"service-code": serviceCode, "format-version": model.CATALOG_FORMAT_VERSION}); err != nil {
+		proc = model.ProcessResult{Result: false, Message: err.Error(), Error: err}
+	} else if location, err := output.Write(ctx, artifact); err != nil {
+		proc = model.ProcessResult{Result: false, Message: err.Error(), Error: err}
 	} else {
-		eProc <- model.ProcessResult{
-			Result:  true,
-			Message: "Process completed",
-		}
+		result.OutputKey = location
+	}
+	select {
+	case eProc <- proc:
+	case <-ctx.Done():
 	}
-
-	// // Write data
-	// if err := WriteOutput(filename, output); err != nil {
-	// 	eProc <- model.ProcessResult{
-	// 		Result:  false,
-	// 		Message: err.Error(),
-	// 	}
-	// } else {
-	// 	eProc <- model.ProcessResult{
-	// 		Result:  true,
-	// 		Message: "Data merger completed",
-	// 	}
-	// }
 }
 
-// func WriteOutput(filename string, output interface{}) error {
-// 	// Create output file
-// 	file, err := module.CreateOutputFile(filename)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	// Transform to byte array
-// 	data, err := json.Marshal(output)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	// Write data
-// 	file.Write(data)
-// 	file.Close()
-
-// 	return nil
-// }
-
 // func transformDataForInstance(rawData model.RawData) map[string]string {
 // 	return map[string]string{
 // 		"instanceFamily":    rawData.Product.Attributes["instanceFamily"],
@@ -0,0 +1,157 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	// Model
+	"aws-price-scanner/model"
+	// Output sink
+	"aws-price-scanner/sink"
+)
+
+// Source that emits a page, then fails
+type failingSource struct{}
+
+func (failingSource) Products(ctx context.Context, def ServiceDefinition, emit func(page []model.RawData) error) error {
+	if err := emit(make([]model.RawData, 10)); err != nil {
+		return err
+	}
+	return errors.New("request failed")
+}
+
+// Sink that keeps nothing
+type discardSink struct{}
+
+func (discardSink) Write(ctx context.Context, artifact sink.Artifact) (string, error) {
+	return artifact.Name, nil
+}
+
+func TestOperatePriceCommandFailureReleasesWorkers(t *testing.T) {
+	def := ServiceDefinition{
+		Code: "Test",
+		Transform: func(rawData model.RawData) model.ProcessedData {
+			return model.ProcessedData{ProductType: "none"}
+		},
+	}
+	before := runtime.NumGoroutine()
+	for i := 0; i < 5; i++ {
+		if _, err := OperatePriceCommand(context.Background(), failingSource{}, def, discardSink{}); err == nil {
+			t.Fatal("expected error of failing source")
+		}
+	}
+	// Workers exit asynchronously after the scan returns
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines leaked: %d before, %d after", before, after)
+	}
+}
+
+func TestOperatePriceCommandCancelReleasesWorkers(t *testing.T) {
+	def := ServiceDefinition{
+		Code: "Test",
+		Transform: func(rawData model.RawData) model.ProcessedData {
+			return model.ProcessedData{ProductType: "none"}
+		},
+	}
+	before := runtime.NumGoroutine()
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := OperatePriceCommand(ctx, failingSource{}, def, discardSink{}); err == nil {
+			t.Fatal("expected error of canceled scan")
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines leaked: %d before, %d after", before, after)
+	}
+}
+
+// Source that records requests and completed requests
+type recordingSource struct {
+	requested []string
+	completed []string
+	failOn    string
+}
+
+func (rs *recordingSource) Products(ctx context.Context, def ServiceDefinition, emit func(page []model.RawData) error) error {
+	rs.requested = append(rs.requested, def.Code+"/"+def.PricingServiceCode)
+	if def.Code == rs.failOn {
+		return errors.New("request failed")
+	}
+	return emit(make([]model.RawData, 1))
+}
+
+func (rs *recordingSource) Complete(ctx context.Context, def ServiceDefinition) error {
+	rs.completed = append(rs.completed, def.Code)
+	return nil
+}
+
+// Sink that fails every write
+type failingSink struct{}
+
+func (failingSink) Write(ctx context.Context, artifact sink.Artifact) (string, error) {
+	return "", errors.New("write failed")
+}
+
+func TestOperatePriceCommandCompletesRequestsAfterOutputIsStored(t *testing.T) {
+	def := ServiceDefinition{
+		Code:               "Test",
+		PricingServiceCode: "Primary",
+		Queries: []Query{
+			{PricingServiceCode: "Other"},
+			{PricingServiceCode: "Other"},
+		},
+		Transform: func(rawData model.RawData) model.ProcessedData {
+			return model.ProcessedData{ProductType: "none"}
+		},
+	}
+	// Every request is done
+	source := &recordingSource{}
+	result, err := OperatePriceCommand(context.Background(), source, def, discardSink{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Test/Primary", "Test.query1/Other", "Test.query2/Other"}; !reflect.DeepEqual(source.requested, want) {
+		t.Errorf("requested %v, want %v", source.requested, want)
+	}
+	if want := []string{"Test", "Test.query1", "Test.query2"}; !reflect.DeepEqual(source.completed, want) {
+		t.Errorf("completed %v, want %v", source.completed, want)
+	}
+	if result.Products != 3 || result.Skipped != 3 {
+		t.Errorf("products %d, skipped %d, want 3 and 3", result.Products, result.Skipped)
+	}
+	// A query fails (the state of done requests is kept)
+	source = &recordingSource{failOn: "Test.query2"}
+	if _, err := OperatePriceCommand(context.Background(), source, def, discardSink{}); err == nil {
+		t.Fatal("expected error of failing query")
+	}
+	if len(source.completed) != 0 {
+		t.Errorf("completed %v after failure, want none", source.completed)
+	}
+	// The output is not stored (the state of every request is kept)
+	source = &recordingSource{}
+	if _, err := OperatePriceCommand(context.Background(), source, def, failingSink{}); err == nil {
+		t.Fatal("expected error of failing sink")
+	}
+	if len(source.requested) != 3 || len(source.completed) != 0 {
+		t.Errorf("requested %v, completed %v after failed write, want every request and none completed", source.requested, source.completed)
+	}
+}
+
+func TestOperatePriceCommandWithoutTransformer(t *testing.T) {
+	if _, err := OperatePriceCommand(context.Background(), &recordingSource{}, ServiceDefinition{Code: "Test"}, discardSink{}); err == nil {
+		t.Fatal("expected error of missing transformer")
+	}
+}
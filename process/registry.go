@@ -0,0 +1,313 @@
+package process
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	// Model
+	"aws-price-scanner/model"
+)
+
+// ServiceDefinition describes how the price list of a service is requested and transformed
+type ServiceDefinition struct {
+	// Public service code (CLI value, output file name)
+	Code string
+	// Service code for AWS pricing API (e.g. EBS products are listed under AmazonEC2)
+	PricingServiceCode string
+	// Default filters for products
+	Filters []model.Filter
+	// Additional requests for products listed under other service codes (e.g. NAT Gateway prices of VPC are listed under AmazonEC2)
+	Queries []Query
+	// Transformer for a product
+	Transform func(rawData model.RawData) model.ProcessedData
+}
+
+// Query is an additional request for products of a service
+type Query struct {
+	// Service code for AWS pricing API
+	PricingServiceCode string `json:"pricingServiceCode" yaml:"pricingServiceCode"`
+	// Filters for products
+	Filters []model.Filter `json:"filters,omitempty" yaml:"filters,omitempty"`
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]ServiceDefinition)
+	registryOrder = make([]string, 0)
+)
+
+func init() {
+	for _, def := range []ServiceDefinition{{
+		Code: model.AWS_SERVICE_CODE_APIGATEWAY,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForApiGateway,
+	}, {
+		Code:      model.AWS_SERVICE_CODE_CLOUDFRONT,
+		Transform: transformPriceDataForCloudFront,
+	}, {
+		Code: model.AWS_SERVICE_CODE_CLOUDWATCH,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForCloudWatch,
+	}, {
+		Code: model.AWS_SERVICE_CODE_DATATRANSFER,
+		Filters: []model.Filter{
+			{Field: "productFamily", Value: "Data Transfer"},
+		},
+		Transform: transformPriceDataForDataTransfer,
+	}, {
+		Code:      model.AWS_SERVICE_CODE_DYNAMODB,
+		Transform: transformPriceDataForDynamoDB,
+	}, {
+		Code:               model.AWS_SERVICE_CODE_EBS,
+		PricingServiceCode: model.AWS_SERVICE_CODE_EC2,
+		Filters: []model.Filter{
+			{Field: "productFamily", Value: "Storage"},
+		},
+		Transform: transformPriceDataForEBS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_EC2,
+		Filters: []model.Filter{
+			{Field: "productFamily", Value: "Compute Instance"},
+			{Field: "currentGeneration", Value: "Yes"},
+			{Field: "marketoption", Value: "OnDemand"},
+		},
+		Queries: []Query{{
+			// Bare metal instance types (e.g. "m5.metal") are a separate product family
+			PricingServiceCode: model.AWS_SERVICE_CODE_EC2,
+			Filters: []model.Filter{
+				{Field: "productFamily", Value: "Compute Instance (bare metal)"},
+				{Field: "currentGeneration", Value: "Yes"},
+				{Field: "marketoption", Value: "OnDemand"},
+			},
+		}, {
+			PricingServiceCode: model.AWS_SERVICE_CODE_EC2,
+			Filters: []model.Filter{
+				{Field: "productFamily", Value: "Dedicated Host"},
+			},
+		}},
+		Transform: transformPriceDataForEC2,
+	}, {
+		Code: model.AWS_SERVICE_CODE_ECR,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForECR,
+	}, {
+		Code: model.AWS_SERVICE_CODE_ECS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "termType", Value: "OnDemand"},
+			{Field: "tenancy", Value: "Shared"},
+			{Field: "productFamily", Value: "Compute"},
+		},
+		Transform: transformPriceDataForECS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_EFS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "termType", Value: "OnDemand"},
+		},
+		Transform: transformPriceDataForEFS,
+	}, {
+		Code:      model.AWS_SERVICE_CODE_EKS,
+		Transform: transformPriceDataForEKS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_ELASTICACHE,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForElastiCache,
+	}, {
+		Code: model.AWS_SERVICE_CODE_ELB,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "termType", Value: "OnDemand"},
+		},
+		Transform: transformPriceDataForELB,
+	}, {
+		Code: model.AWS_SERVICE_CODE_FIREHOSE,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForFirehose,
+	}, {
+		Code: model.AWS_SERVICE_CODE_KINESIS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForKinesis,
+	}, {
+		Code: model.AWS_SERVICE_CODE_KMS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForKMS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_LAMBDA,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "productFamily", Value: "Serverless"},
+		},
+		Transform: transformPriceDataForLambda,
+	}, {
+		Code: model.AWS_SERVICE_CODE_OPENSEARCH,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForOpenSearch,
+	}, {
+		Code: model.AWS_SERVICE_CODE_RDS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForRDS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_REDSHIFT,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForRedshift,
+	}, {
+		Code:      model.AWS_SERVICE_CODE_ROUTE53,
+		Transform: transformPriceDataForRoute53,
+	}, {
+		Code: model.AWS_SERVICE_CODE_S3,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "termType", Value: "onDemand"},
+		},
+		Transform: transformPriceDataForS3,
+	}, {
+		Code: model.AWS_SERVICE_CODE_SECRETSMANAGER,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForSecretsManager,
+	}, {
+		Code:      model.AWS_SERVICE_CODE_SNS,
+		Transform: transformPriceDataForSNS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_SQS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "productFamily", Value: "API Request"},
+		},
+		Transform: transformPriceDataForSQS,
+	}, {
+		Code: model.AWS_SERVICE_CODE_STEPFUNCTIONS,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+		},
+		Transform: transformPriceDataForStepFunctions,
+	}, {
+		Code: model.AWS_SERVICE_CODE_VPC,
+		Filters: []model.Filter{
+			{Field: "locationType", Value: "AWS Region"},
+			{Field: "termType", Value: "onDemand"},
+		},
+		Queries: []Query{{
+			PricingServiceCode: model.AWS_SERVICE_CODE_EC2,
+			Filters: []model.Filter{
+				{Field: "locationType", Value: "AWS Region"},
+				{Field: "productFamily", Value: "NAT Gateway"},
+			},
+		}, {
+			PricingServiceCode: model.AWS_SERVICE_CODE_EC2,
+			Filters: []model.Filter{
+				{Field: "locationType", Value: "AWS Region"},
+				{Field: "productFamily", Value: "IP Address"},
+			},
+		}},
+		Transform: transformPriceDataForVPC,
+	}, {
+		Code:      model.AWS_SERVICE_CODE_WAF,
+		Transform: transformPriceDataForWAF,
+	}} {
+		if err := Register(def); err != nil {
+			panic(err)
+		}
+	}
+}
+
+/*
+ * Register service definition (replace the definition if the service code is already registered)
+ * @param			def {ServiceDefinition} service definition
+ * @response	{error} error object (contain nil)
+ */
+func Register(def ServiceDefinition) error {
+	if def.Code == "" {
+		return errors.New("Service code of service definition is mandatory")
+	} else if def.Code == "all" {
+		return errors.New("Service code \"all\" is reserved")
+	} else if def.Transform == nil {
+		return errors.New("Transformer of service definition is mandatory (" + def.Code + ")")
+	}
+	if def.PricingServiceCode == "" {
+		def.PricingServiceCode = def.Code
+	}
+	for _, query := range def.Queries {
+		if query.PricingServiceCode == "" {
+			return errors.New("Service code of query is mandatory (" + def.Code + ")")
+		}
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if _, ok := registry[def.Code]; !ok {
+		registryOrder = append(registryOrder, def.Code)
+	}
+	registry[def.Code] = def
+	return nil
+}
+
+/*
+ * Find service definition by service code
+ * @param			serviceCode {string} service code
+ * @response	{ServiceDefinition} service definition
+ * @response	{bool} whether service is registered
+ */
+func Lookup(serviceCode string) (ServiceDefinition, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	def, ok := registry[serviceCode]
+	return def, ok
+}
+
+/*
+ * Get a list of registered service code (in registration order)
+ * @response	{[]string} a list of service code
+ */
+func ServiceCodes() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	result := make([]string, len(registryOrder))
+	copy(result, registryOrder)
+	return result
+}
+
+/*
+ * [Method] Split service definition into a definition per request (the definition itself and its additional queries)
+ * The service code of a query is suffixed with its index (e.g. "AmazonVPC.query1"), so that each request has its own checkpoint
+ * (a source keeps the checkpoint of a done request until Completer.Complete is called, so a resumed scan replays it instead of requesting it again).
+ * @response	{[]ServiceDefinition} a list of service definition without queries
+ */
+func (def ServiceDefinition) requests() []ServiceDefinition {
+	result := make([]ServiceDefinition, 0, len(def.Queries)+1)
+	primary := def
+	primary.Queries = nil
+	result = append(result, primary)
+	for i, query := range def.Queries {
+		result = append(result, ServiceDefinition{
+			Code:               def.Code + ".query" + strconv.Itoa(i+1),
+			PricingServiceCode: query.PricingServiceCode,
+			Filters:            query.Filters,
+			Transform:          def.Transform,
+		})
+	}
+	return result
+}
@@ -0,0 +1,410 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+
+	// Model
+	"aws-price-scanner/model"
+)
+
+// RuleFile is the content of a rules file (YAML or JSON, see docs/rules.md)
+type RuleFile struct {
+	Services []ServiceRule `json:"services" yaml:"services"`
+}
+
+// ServiceRule declares filters and classification rules of a service
+type ServiceRule struct {
+	Code               string            `json:"code" yaml:"code"`
+	PricingServiceCode string            `json:"pricingServiceCode,omitempty" yaml:"pricingServiceCode,omitempty"`
+	Filters            []model.Filter    `json:"filters,omitempty" yaml:"filters,omitempty"`
+	Queries            []Query           `json:"queries,omitempty" yaml:"queries,omitempty"`
+	Region             string            `json:"region,omitempty" yaml:"region,omitempty"`
+	Product            map[string]string `json:"product,omitempty" yaml:"product,omitempty"`
+	Inherit            bool              `json:"inherit,omitempty" yaml:"inherit,omitempty"`
+	Rules              []Rule            `json:"rules" yaml:"rules"`
+}
+
+// Rule classifies the products matching all of its conditions
+type Rule struct {
+	Match       []Condition       `json:"match,omitempty" yaml:"match,omitempty"`
+	Skip        bool              `json:"skip,omitempty" yaml:"skip,omitempty"`
+	ProductType string            `json:"productType,omitempty" yaml:"productType,omitempty"`
+	ServiceType string            `json:"serviceType,omitempty" yaml:"serviceType,omitempty"`
+	OnDemandKey string            `json:"onDemandKey,omitempty" yaml:"onDemandKey,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// Condition tests a product field ("productFamily", "sku" or a product attribute)
+type Condition struct {
+	Field    string   `json:"field" yaml:"field"`
+	Equals   *string  `json:"equals,omitempty" yaml:"equals,omitempty"`
+	In       []string `json:"in,omitempty" yaml:"in,omitempty"`
+	Contains string   `json:"contains,omitempty" yaml:"contains,omitempty"`
+	Prefix   string   `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Suffix   string   `json:"suffix,omitempty" yaml:"suffix,omitempty"`
+	Regex    string   `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Exists   *bool    `json:"exists,omitempty" yaml:"exists,omitempty"`
+	Not      bool     `json:"not,omitempty" yaml:"not,omitempty"`
+}
+
+type compiledService struct {
+	region   *template.Template
+	product  map[string]*template.Template
+	rules    []compiledRule
+	fallback func(model.RawData) model.ProcessedData
+}
+
+type compiledRule struct {
+	match       []compiledCondition
+	skip        bool
+	productType *template.Template
+	serviceType *template.Template
+	onDemandKey *template.Template
+	attributes  map[string]*template.Template
+}
+
+type compiledCondition struct {
+	Condition
+	regex *regexp.Regexp
+}
+
+var ruleFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"trim":    strings.TrimSpace,
+	"replace": func(old string, new string, s string) string { return strings.Replace(s, old, new, -1) },
+	"default": func(def string, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+}
+
+/*
+ * Load rules files and register their service definitions (replace built-in definitions with the same service code)
+ * @param			path {string} rules file or directory of rules files (*.yaml, *.yml, *.json)
+ * @response	{[]string} a list of registered service code
+ * @response	{error} error object (contain nil)
+ */
+func RegisterRules(path string) ([]string, error) {
+	defs, err := LoadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(defs))
+	for _, def := range defs {
+		if err := Register(def); err != nil {
+			return nil, err
+		}
+		codes = append(codes, def.Code)
+	}
+	return codes, nil
+}
+
+/*
+ * Load rules files as service definitions
+ * @param			path {string} rules file or directory of rules files (*.yaml, *.yml, *.json)
+ * @response	{[]ServiceDefinition} a list of service definition
+ * @response	{error} error object (contain nil)
+ */
+func LoadRules(path string) ([]ServiceDefinition, error) {
+	// Find rules files
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = make([]string, 0)
+		for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+	// Parse rules files
+	result := make([]ServiceDefinition, 0)
+	for _, filename := range files {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		var file RuleFile
+		if strings.ToLower(filepath.Ext(filename)) == ".json" {
+			// Reject unknown fields, as YAML does (a misspelled field would be ignored)
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.DisallowUnknownFields()
+			err = decoder.Decode(&file)
+		} else {
+			err = yaml.UnmarshalStrict(content, &file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		for _, rule := range file.Services {
+			def, err := rule.compile()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			result = append(result, def)
+		}
+	}
+	return result, nil
+}
+
+func (sr ServiceRule) compile() (ServiceDefinition, error) {
+	if sr.Code == "" {
+		return ServiceDefinition{}, errors.New("Service code of service rule is mandatory")
+	} else if len(sr.Rules) == 0 {
+		return ServiceDefinition{}, errors.New("Service rule has no rule (" + sr.Code + ")")
+	}
+	name := func(field string) string { return sr.Code + "." + field }
+
+	var err error
+	service := &compiledService{product: make(map[string]*template.Template)}
+	// Region (default: region code of product)
+	if sr.Region == "" {
+		sr.Region = "{{.regionCode}}"
+	}
+	if service.region, err = parseRuleTemplate(name("region"), sr.Region); err != nil {
+		return ServiceDefinition{}, err
+	}
+	// Product attributes
+	for key, value := range sr.Product {
+		if service.product[key], err = parseRuleTemplate(name("product."+key), value); err != nil {
+			return ServiceDefinition{}, err
+		}
+	}
+	// Rules
+	for i, rule := range sr.Rules {
+		compiled := compiledRule{skip: rule.Skip, attributes: make(map[string]*template.Template)}
+		for _, condition := range rule.Match {
+			if condition.Field == "" {
+				return ServiceDefinition{}, fmt.Errorf("Field of condition is mandatory (%s rule %d)", sr.Code, i+1)
+			}
+			c := compiledCondition{Condition: condition}
+			if condition.Regex != "" {
+				if c.regex, err = regexp.Compile(condition.Regex); err != nil {
+					return ServiceDefinition{}, fmt.Errorf("%s rule %d: %w", sr.Code, i+1, err)
+				}
+			}
+			compiled.match = append(compiled.match, c)
+		}
+		if !rule.Skip {
+			if rule.ProductType == "" || rule.ServiceType == "" || rule.OnDemandKey == "" {
+				return ServiceDefinition{}, fmt.Errorf("productType, serviceType and onDemandKey are mandatory (%s rule %d)", sr.Code, i+1)
+			}
+			prefix := fmt.Sprintf("rules[%d].", i)
+			if compiled.productType, err = parseRuleTemplate(name(prefix+"productType"), rule.ProductType); err != nil {
+				return ServiceDefinition{}, err
+			}
+			if compiled.serviceType, err = parseRuleTemplate(name(prefix+"serviceType"), rule.ServiceType); err != nil {
+				return ServiceDefinition{}, err
+			}
+			if compiled.onDemandKey, err = parseRuleTemplate(name(prefix+"onDemandKey"), rule.OnDemandKey); err != nil {
+				return ServiceDefinition{}, err
+			}
+			for key, value := range rule.Attributes {
+				if compiled.attributes[key], err = parseRuleTemplate(name(prefix+"attributes."+key), value); err != nil {
+					return ServiceDefinition{}, err
+				}
+			}
+		}
+		service.rules = append(service.rules, compiled)
+	}
+	// Fall back to the registered transformer for unmatched products
+	if sr.Inherit {
+		base, ok := Lookup(sr.Code)
+		if !ok {
+			return ServiceDefinition{}, errors.New("Not found service definition to inherit (" + sr.Code + ")")
+		}
+		service.fallback = base.Transform
+		if sr.PricingServiceCode == "" {
+			sr.PricingServiceCode = base.PricingServiceCode
+		}
+		if sr.Filters == nil {
+			sr.Filters = base.Filters
+		}
+		if sr.Queries == nil {
+			sr.Queries = base.Queries
+		}
+	}
+	// Service code for AWS pricing API (default: service code, as Register)
+	if sr.PricingServiceCode == "" {
+		sr.PricingServiceCode = sr.Code
+	}
+	for _, query := range sr.Queries {
+		if query.PricingServiceCode == "" {
+			return ServiceDefinition{}, errors.New("Service code of query is mandatory (" + sr.Code + ")")
+		}
+	}
+
+	return ServiceDefinition{
+		Code:               sr.Code,
+		PricingServiceCode: sr.PricingServiceCode,
+		Filters:            sr.Filters,
+		Queries:            sr.Queries,
+		Transform:          service.transform,
+	}, nil
+}
+
+func parseRuleTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=zero").Funcs(ruleFuncs).Parse(text)
+}
+
+func executeRuleTemplate(tmpl *template.Template, data map[string]string) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (cc compiledCondition) test(data map[string]string) bool {
+	value, exists := data[cc.Field]
+	result := true
+	if cc.Exists != nil {
+		result = result && exists == *cc.Exists
+	}
+	if cc.Equals != nil {
+		result = result && value == *cc.Equals
+	}
+	if len(cc.In) > 0 {
+		in := false
+		for _, elem := range cc.In {
+			if value == elem {
+				in = true
+				break
+			}
+		}
+		result = result && in
+	}
+	if cc.Contains != "" {
+		result = result && strings.Contains(value, cc.Contains)
+	}
+	if cc.Prefix != "" {
+		result = result && strings.HasPrefix(value, cc.Prefix)
+	}
+	if cc.Suffix != "" {
+		result = result && strings.HasSuffix(value, cc.Suffix)
+	}
+	if cc.regex != nil {
+		result = result && cc.regex.MatchString(value)
+	}
+	return result != cc.Not
+}
+
+func (cs *compiledService) transform(rawData model.RawData) model.ProcessedData {
+	// Set template data (product attributes, product family and sku)
+	data := make(map[string]string, len(rawData.Product.Attributes)+2)
+	for key, value := range rawData.Product.Attributes {
+		data[key] = value
+	}
+	data["productFamily"] = rawData.Product.ProductFamily
+	data["sku"] = rawData.Product.Sku
+
+	// Find the first matching rule
+	for _, rule := range cs.rules {
+		matched := true
+		for _, condition := range rule.match {
+			if !condition.test(data) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		} else if rule.skip {
+			break
+		}
+
+		// Execute templates (the first error is kept)
+		var err error
+		execute := func(tmpl *template.Template) string {
+			value, terr := executeRuleTemplate(tmpl, data)
+			if terr != nil && err == nil {
+				err = terr
+			}
+			return value
+		}
+		attributes := make(map[string]string, len(rule.attributes))
+		for key, tmpl := range rule.attributes {
+			attributes[key] = execute(tmpl)
+		}
+		var product map[string]string
+		if len(cs.product) > 0 {
+			product = make(map[string]string, len(cs.product))
+			for key, tmpl := range cs.product {
+				product[key] = execute(tmpl)
+			}
+		}
+		productType := execute(rule.productType)
+		serviceType := execute(rule.serviceType)
+		onDemandKey := execute(rule.onDemandKey)
+		region := execute(cs.region)
+		// Skip the product if a template fails or gives no product type, so it is not merged under an empty key
+		if err != nil || productType == "" {
+			return skipRawData(rawData)
+		}
+
+		// Set price data
+		onDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand)
+		if len(attributes) > 0 {
+			for i := range onDemand {
+				onDemand[i].Attributes = attributes
+			}
+		}
+		// Set reserved price data
+		reserved := transformDataForReserved(rawData.Terms.Reserved)
+		if len(attributes) > 0 {
+			for i := range reserved {
+				reserved[i].Attributes = attributes
+			}
+		}
+		return model.ProcessedData{
+			OnDemand: map[string][]model.PriceDimension{
+				onDemandKey: onDemand,
+			},
+			Product:     product,
+			ProductType: productType,
+			Region:      region,
+			Reserved: map[string][]model.ReservedTerm{
+				onDemandKey: reserved,
+			},
+			Sku:         rawData.Product.Sku,
+			ServiceType: serviceType,
+			UsageType:   rawData.Product.Attributes["usagetype"],
+		}
+	}
+
+	// Not matched (fall back to the registered transformer or skip)
+	if cs.fallback != nil {
+		return cs.fallback(rawData)
+	}
+	return skipRawData(rawData)
+}
+
+func skipRawData(rawData model.RawData) model.ProcessedData {
+	return model.ProcessedData{
+		ProductType: "none",
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		UsageType:   rawData.Product.Attributes["usagetype"],
+	}
+}
@@ -0,0 +1,279 @@
+package process
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	// Model
+	"aws-price-scanner/model"
+)
+
+func TestCompiledConditionTest(t *testing.T) {
+	empty := ""
+	storage := "Storage"
+	yes := true
+	no := false
+	data := map[string]string{"productFamily": "Storage", "usagetype": "APN1-TimedStorage-ByteHrs", "empty": ""}
+	tests := []struct {
+		name      string
+		condition Condition
+		regex     string
+		expected  bool
+	}{
+		{"equals", Condition{Field: "productFamily", Equals: &storage}, "", true},
+		{"equals other", Condition{Field: "usagetype", Equals: &storage}, "", false},
+		{"equals empty", Condition{Field: "empty", Equals: &empty}, "", true},
+		{"equals empty of missing", Condition{Field: "missing", Equals: &empty}, "", true},
+		{"in", Condition{Field: "productFamily", In: []string{"Compute", "Storage"}}, "", true},
+		{"not in", Condition{Field: "productFamily", In: []string{"Compute"}}, "", false},
+		{"contains", Condition{Field: "usagetype", Contains: "TimedStorage"}, "", true},
+		{"not contains", Condition{Field: "usagetype", Contains: "Requests"}, "", false},
+		{"prefix", Condition{Field: "usagetype", Prefix: "APN1-"}, "", true},
+		{"suffix", Condition{Field: "usagetype", Suffix: "-ByteHrs"}, "", true},
+		{"regex", Condition{Field: "usagetype", Regex: "^[A-Z0-9]+-Timed"}, "^[A-Z0-9]+-Timed", true},
+		{"regex not matched", Condition{Field: "usagetype", Regex: "Requests$"}, "Requests$", false},
+		{"exists", Condition{Field: "empty", Exists: &yes}, "", true},
+		{"exists of missing", Condition{Field: "missing", Exists: &yes}, "", false},
+		{"not exists", Condition{Field: "missing", Exists: &no}, "", true},
+		{"not", Condition{Field: "productFamily", Equals: &storage, Not: true}, "", false},
+		{"all tests", Condition{Field: "usagetype", Prefix: "APN1-", Suffix: "-Requests"}, "", false},
+		{"no test", Condition{Field: "missing"}, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cc := compiledCondition{Condition: test.condition}
+			if test.regex != "" {
+				cc.regex = regexp.MustCompile(test.regex)
+			}
+			if result := cc.test(data); result != test.expected {
+				t.Errorf("result %v, want %v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestServiceRuleTemplates(t *testing.T) {
+	rule := ServiceRule{
+		Code:    "Test",
+		Region:  "{{default \"global\" .regionCode}}",
+		Product: map[string]string{"family": "{{upper .productFamily}}"},
+		Rules: []Rule{{
+			Match: []Condition{{Field: "group", Contains: "skip"}},
+			Skip:  true,
+		}, {
+			Match:       []Condition{{Field: "usagetype", Contains: "Slice"}},
+			ProductType: "slice",
+			ServiceType: "{{slice .usagetype 0 8}}",
+			OnDemandKey: "key",
+		}, {
+			Match:       []Condition{{Field: "productType", Exists: boolPointer(true)}},
+			ProductType: "{{.productType}}",
+			ServiceType: "type",
+			OnDemandKey: "key",
+		}, {
+			ProductType: "{{lower .productFamily}}",
+			ServiceType: "{{replace \" \" \"-\" (lower (trim .storageClass))}}",
+			OnDemandKey: "{{default \"store\" .operation}}",
+			Attributes:  map[string]string{"tier": "{{.tier}}"},
+		}},
+	}
+	def, err := rule.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		attributes  map[string]string
+		productType string
+		serviceType string
+		onDemandKey string
+		region      string
+	}{
+		{"templates", map[string]string{"regionCode": "ap-northeast-2", "storageClass": " Infrequent Access ", "tier": "1"}, "storage", "infrequent-access", "store", "ap-northeast-2"},
+		{"default", map[string]string{"operation": "Read", "storageClass": "General"}, "storage", "general", "Read", "global"},
+		{"skip", map[string]string{"group": "skip me", "regionCode": "us-east-1"}, "none", "", "", "us-east-1"},
+		{"template error", map[string]string{"usagetype": "Slice", "regionCode": "us-east-1"}, "none", "", "", "us-east-1"},
+		{"template", map[string]string{"usagetype": "SliceOfUsage"}, "slice", "SliceOfU", "key", "global"},
+		{"empty product type", map[string]string{"productType": "", "regionCode": "us-east-1"}, "none", "", "", "us-east-1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := def.Transform(newTestProduct("SKU", "Storage", test.attributes, "0.1"))
+			if data.ProductType != test.productType || data.ServiceType != test.serviceType || data.Region != test.region {
+				t.Errorf("productType %q, serviceType %q, region %q, want %q, %q, %q", data.ProductType, data.ServiceType, data.Region, test.productType, test.serviceType, test.region)
+			}
+			if test.productType == "none" {
+				return
+			}
+			if _, ok := data.OnDemand[test.onDemandKey]; !ok || len(data.OnDemand) != 1 {
+				t.Errorf("onDemand keys %v, want %q", data.OnDemand, test.onDemandKey)
+			}
+			if data.Product["family"] != "STORAGE" {
+				t.Errorf("product %v, want family STORAGE", data.Product)
+			}
+		})
+	}
+}
+
+func boolPointer(value bool) *bool {
+	return &value
+}
+
+func TestServiceRuleInherit(t *testing.T) {
+	base, ok := Lookup(model.AWS_SERVICE_CODE_VPC)
+	if !ok {
+		t.Fatal("built-in VPC definition is not registered")
+	}
+	rule := ServiceRule{
+		Code:    model.AWS_SERVICE_CODE_VPC,
+		Inherit: true,
+		Rules: []Rule{{
+			Match:       []Condition{{Field: "usagetype", Suffix: "-Custom"}},
+			ProductType: "custom",
+			ServiceType: "custom",
+			OnDemandKey: "custom",
+		}},
+	}
+	def, err := rule.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.PricingServiceCode != base.PricingServiceCode || !reflect.DeepEqual(def.Filters, base.Filters) || !reflect.DeepEqual(def.Queries, base.Queries) {
+		t.Errorf("definition %+v does not inherit %+v", def, base)
+	}
+
+	// Matched products are classified by the rule, others by the built-in transformer
+	custom := newTestProduct("SKU1", "VpcEndpoint", map[string]string{"usagetype": "APN2-Custom", "regionCode": "ap-northeast-2"}, "0.1")
+	if data := def.Transform(custom); data.ProductType != "custom" {
+		t.Errorf("productType %q, want custom", data.ProductType)
+	}
+	other := newTestProduct("SKU2", "VpcEndpoint", map[string]string{"usagetype": "APN2-VpcEndpoint-Hours", "regionCode": "ap-northeast-2"}, "0.1")
+	if data, expected := def.Transform(other), base.Transform(other); !reflect.DeepEqual(data, expected) {
+		t.Errorf("fallback %+v, want %+v", data, expected)
+	}
+
+	// Queries of the rule replace the built-in queries
+	rule.Queries = []Query{{PricingServiceCode: "AmazonEC2"}}
+	if def, err = rule.compile(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(def.Queries, rule.Queries) {
+		t.Errorf("queries %v, want %v", def.Queries, rule.Queries)
+	}
+
+	// No built-in definition to inherit
+	rule.Code = "Unknown"
+	if _, err := rule.compile(); err == nil {
+		t.Error("expected error of unknown service to inherit")
+	}
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		message  string
+	}{
+		{"unknown field of YAML", "rules.yaml", "services:\n  - code: Test\n    rule: []\n", "rule"},
+		{"unknown field of JSON", "rules.json", `{"services": [{"code": "Test", "rule": []}]}`, "unknown field"},
+		{"invalid JSON", "rules.json", `{"services": [`, "unexpected EOF"},
+		{"no service code", "rules.yaml", "services:\n  - rules:\n      - { productType: a, serviceType: b, onDemandKey: c }\n", "Service code"},
+		{"no rule", "rules.yaml", "services:\n  - code: Test\n", "no rule"},
+		{"no product type", "rules.yaml", "services:\n  - code: Test\n    rules:\n      - { serviceType: b, onDemandKey: c }\n", "mandatory"},
+		{"no field of condition", "rules.yaml", "services:\n  - code: Test\n    rules:\n      - { match: [{ equals: a }], skip: true }\n", "Field"},
+		{"invalid regex", "rules.yaml", "services:\n  - code: Test\n    rules:\n      - { match: [{ field: a, regex: \"(\" }], skip: true }\n", "rule 1"},
+		{"invalid template", "rules.yaml", "services:\n  - code: Test\n    rules:\n      - { productType: \"{{.a\", serviceType: b, onDemandKey: c }\n", "Test.rules[0].productType"},
+		{"unknown function", "rules.yaml", "services:\n  - code: Test\n    rules:\n      - { productType: \"{{title .a}}\", serviceType: b, onDemandKey: c }\n", "title"},
+		{"no service code of query", "rules.yaml", "services:\n  - code: Test\n    queries: [{ filters: [] }]\n    rules:\n      - { productType: a, serviceType: b, onDemandKey: c }\n", "query"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), test.filename)
+			if err := ioutil.WriteFile(filename, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadRules(filename)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), filename) || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("error %q, want file name and %q", err.Error(), test.message)
+			}
+		})
+	}
+
+	if _, err := LoadRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error of missing rules file")
+	}
+}
+
+func TestLoadRulesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.yaml":    "services:\n  - code: B\n    rules:\n      - { productType: a, serviceType: b, onDemandKey: c }\n",
+		"a.json":    `{"services": [{"code": "A", "pricingServiceCode": "AmazonA", "rules": [{"productType": "a", "serviceType": "b", "onDemandKey": "c"}]}]}`,
+		"notes.txt": "not a rules file",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defs, err := LoadRules(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make([]string, len(defs))
+	for i, def := range defs {
+		codes[i] = def.Code + "/" + def.PricingServiceCode
+	}
+	// Files are sorted by name, the pricing service code defaults to the service code
+	if want := []string{"A/AmazonA", "B/B"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("definitions %v, want %v", codes, want)
+	}
+}
+
+func TestEFSRulesMatchBuiltInTransformer(t *testing.T) {
+	defs, err := LoadRules(filepath.Join("..", "rules", "AmazonEFS.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 1 {
+		t.Fatalf("%d definitions, want 1", len(defs))
+	}
+	def := defs[0]
+	base, _ := Lookup(model.AWS_SERVICE_CODE_EFS)
+	if def.Code != base.Code || def.PricingServiceCode != base.PricingServiceCode || !reflect.DeepEqual(def.Filters, base.Filters) {
+		t.Errorf("definition %s/%s %v, want %s/%s %v", def.Code, def.PricingServiceCode, def.Filters, base.Code, base.PricingServiceCode, base.Filters)
+	}
+
+	products := []model.RawData{
+		newTestProduct("SKU1", "Storage", map[string]string{"regionCode": "us-east-1", "storageClass": "General Purpose", "usagetype": "TimedStorage-ByteHrs"}, "0.30"),
+		newTestProduct("SKU2", "Storage", map[string]string{"regionCode": "us-east-1", "storageClass": "Infrequent Access", "operation": "Read", "usagetype": "IATimedStorage-ByteHrs"}, "0.01"),
+		newTestProduct("SKU3", "Provisioned Throughput", map[string]string{"regionCode": "us-east-1", "throughputClass": "Provisioned", "usagetype": "ProvisionedTP-MiBpsHrs"}, "6.00"),
+		newTestProduct("SKU4", "Storage", map[string]string{"regionCode": "eu-west-1", "storageClass": "One Zone", "usagetype": "EU-TimedStorage-Z-ByteHrs"}, "0.18"),
+		newTestProduct("SKU5", "Data Transfer", map[string]string{"regionCode": "us-east-1", "usagetype": "DataTransfer-Out-Bytes"}, "0.09"),
+	}
+	merge := func(transform func(model.RawData) model.ProcessedData) string {
+		catalog := model.NewCatalog(def.Code)
+		for _, rawData := range products {
+			if data := transform(rawData); data.ProductType != "none" {
+				catalog.Merge(data)
+			}
+		}
+		content, err := json.Marshal(catalog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	if result, expected := merge(def.Transform), merge(base.Transform); result != expected {
+		t.Errorf("catalog of rules differs from built-in transformer\n got: %s\nwant: %s", result, expected)
+	}
+}
@@ -1,7 +1,9 @@
 package process
 
 import (
+	"sort"
 	"strings"
+	"unicode"
 
 	// Model
 	"aws-price-scanner/model"
@@ -19,39 +21,311 @@ func transformDataForInstance(rawData model.RawData) map[string]string {
 	}
 }
 
-func transformDataForPricePerUnit(rawData map[string]interface{}) []map[string]interface{} {
-	// Find price dimension
-	var respData map[string]interface{}
-	for _, value := range rawData {
-		respData = value.(map[string]interface{})
-		break
+func transformDataForLocationKey(location string) string {
+	// Key of a location name (e.g. edge geography, country: "United States" -> "united-states")
+	words := strings.FieldsFunc(strings.ToLower(location), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+func transformDataForTransferLocation(rawData model.RawData, prefix string) string {
+	// Region code of location ("from" or "to"), "internet" for the internet, otherwise key of location name
+	if code := rawData.Product.Attributes[prefix+"RegionCode"]; code != "" {
+		return code
 	}
-	for key, value := range respData {
-		if key == "priceDimensions" {
-			respData = value.(map[string]interface{})
-			break
-		}
+	location := rawData.Product.Attributes[prefix+"Location"]
+	if location == "External" {
+		return "internet"
+	} else if code, ok := model.RegionCode(location); ok {
+		return code
+	}
+	return transformDataForLocationKey(location)
+}
+
+func transformDataForPricePerUnit(terms map[string]model.RawTerm) []model.PriceDimension {
+	// Find price dimension (of the first term)
+	keys := make([]string, 0, len(terms))
+	for key := range terms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var dimensions map[string]model.RawPriceDimension
+	if len(keys) > 0 {
+		dimensions = terms[keys[0]].PriceDimensions
 	}
 	// Extract price data
-	cnt := 0
-	result := make([]map[string]interface{}, len(respData))
-	for _, value := range respData {
-		obj := make(map[string]interface{})
-		data := value.(map[string]interface{})
-		for key, value := range data {
-			if key == "beginRange" || key == "description" || key == "endRange" || key == "unit" {
-				obj[key] = value.(string)
-			} else if key == "pricePerUnit" {
-				obj["pricePerUnit"] = value
+	result := make([]model.PriceDimension, 0, len(dimensions))
+	for _, data := range dimensions {
+		result = append(result, model.PriceDimension{
+			BeginRange:   data.BeginRange,
+			Description:  data.Description,
+			EndRange:     data.EndRange,
+			Price:        model.ParsePrices(data.PricePerUnit),
+			PricePerUnit: data.PricePerUnit,
+			Range:        model.ParseRange(data.BeginRange, data.EndRange),
+			Unit:         data.Unit,
+		})
+	}
+	// Sort by range (tiers in ascending order)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Range != nil && result[j].Range != nil {
+			if cmp := result[i].Range.Begin.Cmp(result[j].Range.Begin); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return result[i].Description < result[j].Description
+	})
+	// Return
+	return result
+}
+
+func transformDataForReserved(terms map[string]model.RawTerm) []model.ReservedTerm {
+	result := make([]model.ReservedTerm, 0, len(terms))
+	for _, term := range terms {
+		reserved := model.ReservedTerm{
+			LeaseContractLength: term.TermAttributes["LeaseContractLength"],
+			PurchaseOption:      term.TermAttributes["PurchaseOption"],
+			OfferingClass:       term.TermAttributes["OfferingClass"],
+		}
+		// Split upfront fee (unit "Quantity") and recurring fee
+		for _, data := range term.PriceDimensions {
+			if data.Unit == "Quantity" {
+				reserved.Upfront = model.ParsePrices(data.PricePerUnit)
+				reserved.UpfrontFee = data.PricePerUnit
+			} else {
+				reserved.Recurring = model.ParsePrices(data.PricePerUnit)
+				reserved.RecurringFee = data.PricePerUnit
+				reserved.RecurringUnit = data.Unit
 			}
 		}
-		result[cnt] = obj
-		cnt++
+		result = append(result, reserved)
 	}
+	// Sort by lease contract length, purchase option and offering class
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LeaseContractLength != result[j].LeaseContractLength {
+			return result[i].LeaseContractLength < result[j].LeaseContractLength
+		} else if result[i].PurchaseOption != result[j].PurchaseOption {
+			return result[i].PurchaseOption < result[j].PurchaseOption
+		}
+		return result[i].OfferingClass < result[j].OfferingClass
+	})
 	// Return
 	return result
 }
 
+func transformPriceDataForApiGateway(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	serviceType := "api"
+	var onDemandKey string
+	if strings.Contains(rawData.Product.ProductFamily, "Cache") {
+		productType = "cache"
+		serviceType = rawData.Product.Attributes["cacheMemorySizeGb"] + "GB"
+		onDemandKey = "usage"
+		if rawData.Product.Attributes["cacheMemorySizeGb"] == "" {
+			productType = "none"
+		}
+	} else if strings.Contains(usageType, "ApiGatewayMessage") {
+		productType = "websocket"
+		onDemandKey = "messages"
+	} else if strings.Contains(usageType, "ApiGatewayMinute") {
+		productType = "websocket"
+		onDemandKey = "connectionMinutes"
+	} else if strings.Contains(usageType, "ApiGatewayHttpRequest") || strings.Contains(usageType, "ApiGatewayHttpApi") {
+		productType = "http"
+		onDemandKey = "requests"
+	} else if strings.Contains(usageType, "ApiGatewayRequest") {
+		productType = "rest"
+		onDemandKey = "requests"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForCloudFront(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	requestType := rawData.Product.Attributes["requestType"]
+	// Set product type, service type, onDemand key and edge geography
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	var location string
+	if rawData.Product.ProductFamily == "Data Transfer" {
+		location = rawData.Product.Attributes["fromLocation"]
+		productType = "dataTransfer"
+		onDemandKey = "transfer"
+		if strings.Contains(rawData.Product.Attributes["transferType"], "Origin") {
+			serviceType = "toOrigin"
+		} else if strings.Contains(rawData.Product.Attributes["transferType"], "Outbound") {
+			serviceType = "toInternet"
+		} else {
+			productType = "none"
+		}
+	} else if rawData.Product.ProductFamily == "Request" {
+		location = rawData.Product.Attributes["location"]
+		if strings.Contains(requestType, "OriginShield") || strings.Contains(usageType, "OriginShield") || strings.Contains(usageType, "-OS-") {
+			productType = "originShield"
+			serviceType = "request"
+		} else {
+			productType = "request"
+			if strings.Contains(requestType, "HTTPS") || strings.Contains(usageType, "HTTPS") {
+				serviceType = "https"
+			} else {
+				serviceType = "http"
+			}
+		}
+		if strings.Contains(requestType, "Proxy") {
+			onDemandKey = "proxy"
+		} else {
+			onDemandKey = "requests"
+		}
+	}
+	// Edge geography is mandatory
+	edge := transformDataForLocationKey(location)
+	if edge == "" {
+		productType = "none"
+	}
+	// Return
+	return model.ProcessedData{
+		Edge: edge,
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"location": location,
+		},
+		ProductType: productType,
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForCloudWatch(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "MetricMonitorUsage") {
+		productType = "metric"
+		serviceType = "custom"
+		onDemandKey = "metrics"
+	} else if strings.Contains(usageType, "CW:Requests") || strings.Contains(usageType, "CW:GMD-Metrics") {
+		productType = "api"
+		if strings.Contains(usageType, "GMD") {
+			serviceType = "getMetricData"
+		} else {
+			serviceType = "standard"
+		}
+		onDemandKey = "requests"
+	} else if strings.Contains(usageType, "DataProcessing-Bytes") {
+		productType = "logs"
+		serviceType = "ingestion"
+		onDemandKey = "standard"
+	} else if strings.Contains(usageType, "DataProcessingIA-Bytes") {
+		productType = "logs"
+		serviceType = "ingestion"
+		onDemandKey = "infrequentAccess"
+	} else if strings.Contains(usageType, "TimedStorage-ByteHrs") {
+		productType = "logs"
+		serviceType = "storage"
+		onDemandKey = "storage"
+	} else if strings.Contains(usageType, "DataScanned-Bytes") {
+		productType = "logs"
+		serviceType = "insights"
+		onDemandKey = "scanned"
+	} else if strings.Contains(usageType, "AlarmMonitorUsage") {
+		productType = "alarm"
+		if strings.Contains(usageType, "HighResAlarm") {
+			serviceType = "highResolution"
+		} else if strings.Contains(usageType, "CompositeAlarm") {
+			serviceType = "composite"
+		} else {
+			serviceType = "standard"
+		}
+		onDemandKey = "alarms"
+	} else if strings.Contains(usageType, "DashboardsUsageHour") {
+		productType = "dashboard"
+		serviceType = "dashboard"
+		onDemandKey = "dashboards"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"description": rawData.Product.Attributes["groupDescription"],
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForDataTransfer(rawData model.RawData) model.ProcessedData {
+	// Set route
+	route := &model.TransferRoute{
+		From: transformDataForTransferLocation(rawData, "from"),
+		To:   transformDataForTransferLocation(rawData, "to"),
+	}
+	// Set transfer type
+	transferType := rawData.Product.Attributes["transferType"]
+	var serviceType string
+	switch transferType {
+	case "AWS Outbound":
+		serviceType = "internetOut"
+	case "AWS Inbound":
+		serviceType = "internetIn"
+	case "InterRegion Outbound":
+		serviceType = "interRegionOut"
+	case "InterRegion Inbound":
+		serviceType = "interRegionIn"
+	case "IntraRegion":
+		// Between availability zones of a region
+		serviceType = "intraRegion"
+	default:
+		serviceType = transformDataForLocationKey(transferType)
+	}
+	// Set product type
+	productType := "dataTransfer"
+	if route.From == "" || route.To == "" || serviceType == "" {
+		productType = "none"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			"transfer": transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"fromLocation":     rawData.Product.Attributes["fromLocation"],
+			"fromLocationType": rawData.Product.Attributes["fromLocationType"],
+			"toLocation":       rawData.Product.Attributes["toLocation"],
+			"toLocationType":   rawData.Product.Attributes["toLocationType"],
+			"transferType":     transferType,
+		},
+		ProductType: productType,
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		Transfer:    route,
+		UsageType:   rawData.Product.Attributes["usagetype"],
+	}
+}
+
 func transformPriceDataForDynamoDB(rawData model.RawData) model.ProcessedData {
 	// Set product type
 	productType := "none"
@@ -83,8 +357,8 @@ func transformPriceDataForDynamoDB(rawData model.RawData) model.ProcessedData {
 	}
 
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			"operation": transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			"operation": transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		Product: map[string]string{
 			"description": rawData.Product.Attributes["groupDescription"],
@@ -92,6 +366,9 @@ func transformPriceDataForDynamoDB(rawData model.RawData) model.ProcessedData {
 		},
 		ProductType: productType,
 		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			"operation": transformDataForReserved(rawData.Terms.Reserved),
+		},
 		Sku:         rawData.Product.Sku,
 		ServiceType: serviceType,
 		UsageType:   rawData.Product.Attributes["usagetype"],
@@ -100,8 +377,8 @@ func transformPriceDataForDynamoDB(rawData model.RawData) model.ProcessedData {
 
 func transformPriceDataForEBS(rawData model.RawData) model.ProcessedData {
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			"operation": transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			"operation": transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		Product: map[string]string{
 			"maxIopsvolume":       rawData.Product.Attributes["maxIopsvolume"],
@@ -121,33 +398,96 @@ func transformPriceDataForEBS(rawData model.RawData) model.ProcessedData {
 func transformPriceDataForEC2(rawData model.RawData) model.ProcessedData {
 	// Get operation code
 	operationCode := rawData.Product.Attributes["operation"]
+	// Set product type (by tenancy) and service type
+	productType := "none"
+	serviceType := rawData.Product.Attributes["instanceType"]
+	var product map[string]string
+	if rawData.Product.ProductFamily == "Dedicated Host" {
+		productType = "host"
+		product = map[string]string{
+			"instanceFamily":    rawData.Product.Attributes["instanceFamily"],
+			"instanceType":      rawData.Product.Attributes["instanceType"],
+			"physicalCores":     rawData.Product.Attributes["physicalCores"],
+			"physicalProcessor": rawData.Product.Attributes["physicalProcessor"],
+		}
+	} else {
+		if rawData.Product.Attributes["tenancy"] == "Shared" {
+			productType = "instance"
+		} else if rawData.Product.Attributes["tenancy"] == "Dedicated" {
+			productType = "dedicated"
+		}
+		product = transformDataForInstance(rawData)
+	}
+	// Set onDemand key (capacity reservation variants under the operation code suffixed with the capacity status)
+	onDemandKey := operationCode
+	if capacityStatus := rawData.Product.Attributes["capacitystatus"]; capacityStatus != "" && capacityStatus != "Used" {
+		onDemandKey += ":" + capacityStatus
+	}
 	// Extract price data
-	rawOnDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{}))
+	rawOnDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand)
+	// Set attributes of price data
+	priceAttributes := map[string]string{
+		"capacityStatus":  rawData.Product.Attributes["capacitystatus"],
+		"operatingSystem": rawData.Product.Attributes["operatingSystem"],
+		"preInstalledSw":  rawData.Product.Attributes["preInstalledSw"],
+		"tenancy":         rawData.Product.Attributes["tenancy"],
+	}
 	// Set price data
-	onDemand := make([]map[string]interface{}, len(rawOnDemand))
+	onDemand := make([]model.PriceDimension, len(rawOnDemand))
 	for i, data := range rawOnDemand {
-		onDemand[i] = map[string]interface{}{
-			"operatingSystem": rawData.Product.Attributes["operatingSystem"],
-			"preInstalledSw":  rawData.Product.Attributes["preInstalledSw"],
-		}
-		for key, value := range data {
-			onDemand[i][key] = value
-		}
+		data.Attributes = priceAttributes
+		onDemand[i] = data
+	}
+	// Set reserved price data
+	reserved := transformDataForReserved(rawData.Terms.Reserved)
+	for i := range reserved {
+		reserved[i].Attributes = priceAttributes
 	}
 	// Return
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			operationCode: onDemand,
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: onDemand,
 		},
-		Product:     transformDataForInstance(rawData),
-		ProductType: "instance",
+		Product:     product,
+		ProductType: productType,
 		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			onDemandKey: reserved,
+		},
 		Sku:         rawData.Product.Sku,
-		ServiceType: rawData.Product.Attributes["instanceType"],
+		ServiceType: serviceType,
 		UsageType:   rawData.Product.Attributes["usagetype"],
 	}
 }
 
+func transformPriceDataForECR(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "TimedStorage") {
+		productType = "storage"
+		serviceType = "repository"
+		onDemandKey = "storage"
+	} else if strings.Contains(usageType, "Replication") {
+		productType = "replication"
+		serviceType = "crossRegion"
+		onDemandKey = "transfer"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
 func transformPriceDataForECS(rawData model.RawData) model.ProcessedData {
 	// Set product type
 	productType := "none"
@@ -176,8 +516,8 @@ func transformPriceDataForECS(rawData model.RawData) model.ProcessedData {
 	}
 	// Return
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			operatingSystem: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			operatingSystem: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		Product: map[string]string{
 			"cpuType": rawData.Product.Attributes["cputype"],
@@ -213,8 +553,8 @@ func transformPriceDataForEFS(rawData model.RawData) model.ProcessedData {
 	}
 
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			operation: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			operation: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		ProductType: productType,
 		Region:      rawData.Product.Attributes["regionCode"],
@@ -224,6 +564,119 @@ func transformPriceDataForEFS(rawData model.RawData) model.ProcessedData {
 	}
 }
 
+func transformPriceDataForEKS(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "Fargate") {
+		// EKS on Fargate (same layout as ECS Fargate, Linux only)
+		productType = "fargate"
+		if strings.Contains(usageType, "EphemeralStorage") {
+			serviceType = "storage"
+		} else if strings.Contains(usageType, "vCPU") {
+			serviceType = "cpu"
+		} else if strings.Contains(usageType, "GB") {
+			serviceType = "memory"
+		} else {
+			productType = "none"
+		}
+		onDemandKey = "linux"
+		if rawData.Product.Attributes["cpuArchitecture"] == "ARM" {
+			onDemandKey = "linux-arm"
+		}
+	} else if strings.Contains(usageType, "Anywhere") || strings.Contains(rawData.Product.ProductFamily, "Anywhere") {
+		productType = "anywhere"
+		serviceType = "subscription"
+		onDemandKey = "subscription"
+	} else if strings.Contains(usageType, "AmazonEKS-Hours") {
+		productType = "cluster"
+		if strings.Contains(strings.ToLower(usageType), "extendedsupport") {
+			serviceType = "extendedSupport"
+		} else {
+			serviceType = "standard"
+		}
+		onDemandKey = "usage"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"description": rawData.Product.Attributes["groupDescription"],
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			onDemandKey: transformDataForReserved(rawData.Terms.Reserved),
+		},
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForElastiCache(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set engine (redis, memcached, valkey)
+	engine := strings.ToLower(rawData.Product.Attributes["cacheEngine"])
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	var product map[string]string
+	if rawData.Product.ProductFamily == "Cache Instance" && engine != "" {
+		productType = "node"
+		serviceType = rawData.Product.Attributes["instanceType"]
+		onDemandKey = engine
+		product = transformDataForInstance(rawData)
+	} else if strings.Contains(rawData.Product.ProductFamily, "Serverless") || strings.Contains(usageType, "Serverless") {
+		productType = "serverless"
+		serviceType = engine
+		if strings.Contains(usageType, "ECPU") {
+			onDemandKey = "ecpu"
+		} else if strings.Contains(usageType, "CachedData") || strings.Contains(usageType, "Storage") {
+			onDemandKey = "dataStorage"
+		} else {
+			productType = "none"
+		}
+		if serviceType == "" {
+			productType = "none"
+		}
+	}
+	// Set attributes of price data
+	priceAttributes := map[string]string{
+		"cacheEngine": rawData.Product.Attributes["cacheEngine"],
+	}
+	// Set price data
+	onDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand)
+	for i := range onDemand {
+		onDemand[i].Attributes = priceAttributes
+	}
+	// Set reserved price data (reserved nodes)
+	reserved := transformDataForReserved(rawData.Terms.Reserved)
+	for i := range reserved {
+		reserved[i].Attributes = priceAttributes
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: onDemand,
+		},
+		Product:     product,
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			onDemandKey: reserved,
+		},
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
 func transformPriceDataForELB(rawData model.RawData) model.ProcessedData {
 	// Set service type
 	var serviceType string
@@ -257,8 +710,8 @@ func transformPriceDataForELB(rawData model.RawData) model.ProcessedData {
 	}
 
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		Product: map[string]string{
 			"description": rawData.Product.Attributes["groupDescription"],
@@ -271,6 +724,135 @@ func transformPriceDataForELB(rawData model.RawData) model.ProcessedData {
 	}
 }
 
+func transformPriceDataForFirehose(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type and service type
+	productType := "none"
+	var serviceType string
+	if strings.Contains(usageType, "FormatConversion") {
+		productType = "formatConversion"
+		serviceType = "conversion"
+	} else if strings.Contains(usageType, "DynamicPartitioning") {
+		productType = "dynamicPartitioning"
+		serviceType = "partitioning"
+	} else if strings.Contains(usageType, "Vpc") || strings.Contains(usageType, "VPC") {
+		productType = "vpcDelivery"
+		serviceType = "delivery"
+	} else if strings.Contains(usageType, "BilledBytes") {
+		// Ingestion tiers by source
+		productType = "ingestion"
+		if strings.Contains(usageType, "Vended") {
+			serviceType = "vendedLogs"
+		} else if strings.Contains(usageType, "Kinesis") {
+			serviceType = "kinesisStream"
+		} else {
+			serviceType = "directPut"
+		}
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			"usage": transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"description": rawData.Product.Attributes["groupDescription"],
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForKinesis(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type (capacity mode)
+	productType := "provisioned"
+	if strings.Contains(usageType, "OnDemand") {
+		productType = "onDemand"
+	}
+	// Set service type
+	var serviceType string
+	switch {
+	case strings.Contains(usageType, "EnhancedFanout") && strings.Contains(usageType, "ShardHour"):
+		productType = "enhancedFanOut"
+		serviceType = "consumerShard"
+	case strings.Contains(usageType, "EnhancedFanout"):
+		productType = "enhancedFanOut"
+		serviceType = "dataRetrieval"
+	case strings.Contains(usageType, "LongTerm"):
+		serviceType = "longTermRetention"
+	case strings.Contains(usageType, "Extended"):
+		serviceType = "extendedRetention"
+	case strings.Contains(usageType, "StreamHour"):
+		serviceType = "stream"
+	case strings.Contains(usageType, "ShardHour"):
+		serviceType = "shard"
+	case strings.Contains(usageType, "PutRequestPayloadUnits"):
+		serviceType = "putPayloadUnit"
+	case strings.Contains(usageType, "Incoming"):
+		serviceType = "dataIn"
+	case strings.Contains(usageType, "Outgoing"):
+		serviceType = "dataOut"
+	default:
+		productType = "none"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			"usage": transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"description": rawData.Product.Attributes["groupDescription"],
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForKMS(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "KMS-Requests") {
+		// Requests by key type
+		productType = "request"
+		if strings.Contains(usageType, "GenerateDataKeyPair") {
+			serviceType = "generateDataKeyPair"
+		} else if strings.Contains(usageType, "Asymmetric") || strings.Contains(usageType, "RSA") || strings.Contains(usageType, "ECC") {
+			serviceType = "asymmetric"
+		} else {
+			serviceType = "symmetric"
+		}
+		onDemandKey = "requests"
+	} else if strings.Contains(usageType, "KMS-CustomKeyStore") {
+		productType = "key"
+		serviceType = "customKeyStore"
+		onDemandKey = "monthly"
+	} else if strings.Contains(usageType, "KMS-Keys") {
+		productType = "key"
+		serviceType = "customerManaged"
+		onDemandKey = "monthly"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
 func transformPriceDataForLambda(rawData model.RawData) model.ProcessedData {
 	// Check free tier
 	region := rawData.Product.Attributes["regionCode"]
@@ -303,8 +885,8 @@ func transformPriceDataForLambda(rawData model.RawData) model.ProcessedData {
 	}
 
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		ProductType: productType,
 		Region:      region,
@@ -314,37 +896,355 @@ func transformPriceDataForLambda(rawData model.RawData) model.ProcessedData {
 	}
 }
 
+func transformPriceDataForOpenSearch(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	instanceType := rawData.Product.Attributes["instanceType"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	var product map[string]string
+	if strings.Contains(usageType, "OCU") {
+		// OpenSearch Serverless
+		productType = "serverless"
+		if strings.Contains(usageType, "Indexing") {
+			serviceType = "indexing"
+		} else if strings.Contains(usageType, "Search") {
+			serviceType = "search"
+		} else {
+			productType = "none"
+		}
+		onDemandKey = "ocu"
+	} else if strings.Contains(rawData.Product.ProductFamily, "Instance") && instanceType != "" {
+		// Data and master nodes (UltraWarm nodes are grouped separately)
+		if strings.HasPrefix(instanceType, "ultrawarm") {
+			productType = "ultraWarm"
+		} else {
+			productType = "instance"
+		}
+		serviceType = instanceType
+		onDemandKey = "usage"
+		product = transformDataForInstance(rawData)
+	} else if strings.Contains(rawData.Product.ProductFamily, "Volume") || strings.Contains(rawData.Product.ProductFamily, "Storage") {
+		storageMedia := rawData.Product.Attributes["storageMedia"]
+		if strings.Contains(usageType, "Cold") || strings.Contains(storageMedia, "Cold") {
+			productType = "coldStorage"
+			serviceType = "managed"
+			onDemandKey = "storage"
+		} else if strings.Contains(usageType, "Managed-Storage") || strings.Contains(storageMedia, "Managed") {
+			productType = "ultraWarm"
+			serviceType = "managedStorage"
+			onDemandKey = "storage"
+		} else {
+			// EBS-backed storage of data nodes
+			productType = "storage"
+			serviceType = strings.ToLower(storageMedia)
+			if strings.Contains(usageType, "IOPS") {
+				onDemandKey = "iops"
+			} else if strings.Contains(usageType, "Throughput") {
+				onDemandKey = "throughput"
+			} else {
+				onDemandKey = "storage"
+			}
+			if serviceType == "" {
+				productType = "none"
+			}
+		}
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product:     product,
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			onDemandKey: transformDataForReserved(rawData.Terms.Reserved),
+		},
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
 func transformPriceDataForRDS(rawData model.RawData) model.ProcessedData {
-	// Get operation code
+	// Database instance (keyed by operation code and deployment option)
+	if rawData.Product.ProductFamily == "Database Instance" {
+		return transformPriceDataForRDSInstance(rawData)
+	}
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set deployment option (onDemand key of storage, IOPS and throughput)
+	deploymentOption := rawData.Product.Attributes["deploymentOption"]
+	if deploymentOption == "" {
+		deploymentOption = "operation"
+	}
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	switch rawData.Product.ProductFamily {
+	case "Database Storage":
+		productType = "storage"
+		onDemandKey = deploymentOption
+		switch volumeType := rawData.Product.Attributes["volumeType"]; {
+		case volumeType == "General Purpose-GP3":
+			serviceType = "gp3"
+		case volumeType == "General Purpose":
+			serviceType = "gp2"
+		case volumeType == "Provisioned IOPS-IO2" || strings.Contains(usageType, "IO2"):
+			serviceType = "io2"
+		case volumeType == "Provisioned IOPS":
+			serviceType = "io1"
+		case volumeType == "Magnetic":
+			serviceType = "magnetic"
+		case strings.Contains(volumeType, "Aurora") && strings.Contains(usageType, "IO-Optimized"):
+			serviceType = "aurora-io-optimized"
+		case strings.Contains(volumeType, "Aurora"):
+			serviceType = "aurora"
+		default:
+			productType = "none"
+		}
+	case "Provisioned IOPS":
+		productType = "iops"
+		onDemandKey = deploymentOption
+		if strings.Contains(usageType, "GP3") {
+			serviceType = "gp3"
+		} else if strings.Contains(usageType, "IO2") {
+			serviceType = "io2"
+		} else {
+			serviceType = "io1"
+		}
+	case "Provisioned Throughput":
+		productType = "throughput"
+		serviceType = "gp3"
+		onDemandKey = deploymentOption
+	case "Storage Snapshot":
+		productType = "backup"
+		onDemandKey = "storage"
+		if strings.Contains(usageType, "Aurora") {
+			serviceType = "aurora"
+		} else if strings.Contains(usageType, "ChargedBackupUsage") {
+			serviceType = "rds"
+		} else {
+			productType = "none"
+		}
+	case "System Operation":
+		// Aurora I/O requests
+		if strings.Contains(usageType, "StorageIOUsage") {
+			productType = "io"
+			serviceType = "aurora"
+			onDemandKey = "requests"
+		}
+	case "ServerlessV2":
+		productType = "serverless"
+		serviceType = "v2"
+		if strings.Contains(usageType, "IOOptimized") {
+			onDemandKey = "ioOptimized"
+		} else {
+			onDemandKey = "standard"
+		}
+	case "RDS Proxy":
+		productType = "proxy"
+		if strings.Contains(usageType, "Serverless") {
+			serviceType = "serverless"
+		} else {
+			serviceType = "provisioned"
+		}
+		onDemandKey = "usage"
+	}
+	// Set attributes of price data
+	priceAttributes := map[string]string{
+		"databaseEngine": rawData.Product.Attributes["databaseEngine"],
+	}
+	// Set price data
+	onDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand)
+	for i := range onDemand {
+		onDemand[i].Attributes = priceAttributes
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: onDemand,
+		},
+		Product: map[string]string{
+			"description": rawData.Product.Attributes["groupDescription"],
+			"volumeType":  rawData.Product.Attributes["volumeType"],
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForRDSInstance(rawData model.RawData) model.ProcessedData {
+	// Set onDemand key (Aurora I/O-Optimized suffixed with ":IOOptimized", deployments other than Single-AZ with ":<deploymentOption>")
 	operationCode := rawData.Product.Attributes["operation"]
+	if strings.Contains(rawData.Product.Attributes["usagetype"], "IOOptimized") {
+		operationCode += ":IOOptimized"
+	}
+	if deploymentOption := rawData.Product.Attributes["deploymentOption"]; deploymentOption != "" && deploymentOption != "Single-AZ" {
+		operationCode += ":" + deploymentOption
+	}
+	// Skip previous generation
+	productType := "instance"
+	if rawData.Product.Attributes["currentGeneration"] != "Yes" {
+		productType = "none"
+	}
 	// Extract price data
-	rawOnDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{}))
+	rawOnDemand := transformDataForPricePerUnit(rawData.Terms.OnDemand)
+	// Set attributes of price data
+	priceAttributes := map[string]string{
+		"deploymentOption": rawData.Product.Attributes["deploymentOption"],
+		"databaseEdition":  rawData.Product.Attributes["databaseEdition"],
+		"databaseEngine":   rawData.Product.Attributes["databaseEngine"],
+	}
 	// Set price data
-	onDemand := make([]map[string]interface{}, len(rawOnDemand))
+	onDemand := make([]model.PriceDimension, len(rawOnDemand))
 	for i, data := range rawOnDemand {
-		onDemand[i] = map[string]interface{}{
-			"deploymentOption": rawData.Product.Attributes["deploymentOption"],
-			"databaseEdition":  rawData.Product.Attributes["databaseEdition"],
-			"databaseEngine":   rawData.Product.Attributes["databaseEngine"],
-		}
-		for key, value := range data {
-			onDemand[i][key] = value
-		}
+		data.Attributes = priceAttributes
+		onDemand[i] = data
+	}
+	// Set reserved price data
+	reserved := transformDataForReserved(rawData.Terms.Reserved)
+	for i := range reserved {
+		reserved[i].Attributes = priceAttributes
 	}
 	// Return
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
+		OnDemand: map[string][]model.PriceDimension{
 			operationCode: onDemand,
 		},
 		Product:     transformDataForInstance(rawData),
-		ProductType: "instance",
+		ProductType: productType,
 		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			operationCode: reserved,
+		},
 		Sku:         rawData.Product.Sku,
 		ServiceType: rawData.Product.Attributes["instanceType"],
 		UsageType:   rawData.Product.Attributes["usagetype"],
 	}
 }
 
+func transformPriceDataForRedshift(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	var product map[string]string
+	if rawData.Product.ProductFamily == "Compute Instance" {
+		productType = "node"
+		serviceType = rawData.Product.Attributes["instanceType"]
+		onDemandKey = "usage"
+		product = transformDataForInstance(rawData)
+	} else if strings.Contains(rawData.Product.ProductFamily, "Managed Storage") || strings.Contains(usageType, "RMS") {
+		productType = "storage"
+		serviceType = "managed"
+		onDemandKey = "storage"
+	} else if strings.Contains(rawData.Product.ProductFamily, "Serverless") || strings.Contains(usageType, "RedshiftServerless") {
+		productType = "serverless"
+		serviceType = "rpu"
+		onDemandKey = "usage"
+	} else if strings.Contains(rawData.Product.ProductFamily, "Data Scan") || strings.Contains(usageType, "Spectrum") {
+		productType = "spectrum"
+		serviceType = "scan"
+		onDemandKey = "scanned"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product:     product,
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Reserved: map[string][]model.ReservedTerm{
+			onDemandKey: transformDataForReserved(rawData.Terms.Reserved),
+		},
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForRoute53(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "Resolver") || strings.Contains(usageType, "Firewall") {
+		// Resolver (regional)
+		productType = "resolver"
+		if strings.Contains(usageType, "Firewall") {
+			serviceType = "firewall"
+			onDemandKey = "queries"
+		} else if strings.Contains(usageType, "Queries") {
+			serviceType = "query"
+			onDemandKey = "queries"
+		} else if strings.Contains(usageType, "NetworkInterface") || strings.Contains(usageType, "Endpoint") {
+			serviceType = "endpoint"
+			onDemandKey = "usage"
+		} else {
+			productType = "none"
+		}
+	} else if rawData.Product.ProductFamily == "DNS Zone" {
+		productType = "hostedZone"
+		serviceType = "standard"
+		onDemandKey = "zones"
+	} else if rawData.Product.ProductFamily == "DNS Query" {
+		productType = "query"
+		if strings.Contains(usageType, "LBR") {
+			serviceType = "latency"
+		} else if strings.Contains(usageType, "Geo") {
+			serviceType = "geo"
+		} else if strings.Contains(usageType, "IPBR") {
+			serviceType = "ipBased"
+		} else {
+			serviceType = "standard"
+		}
+		onDemandKey = "queries"
+	} else if rawData.Product.ProductFamily == "DNS Health Check" {
+		productType = "healthCheck"
+		if strings.Contains(usageType, "NonAWS") {
+			serviceType = "nonAws"
+		} else {
+			serviceType = "aws"
+		}
+		// Set optional feature
+		switch {
+		case strings.Contains(usageType, "HTTPS"):
+			onDemandKey = "https"
+		case strings.Contains(usageType, "String-Match"):
+			onDemandKey = "stringMatching"
+		case strings.Contains(usageType, "Fast-Interval"):
+			onDemandKey = "fastInterval"
+		case strings.Contains(usageType, "Latency-Measurement"):
+			onDemandKey = "latencyMeasurement"
+		default:
+			onDemandKey = "basic"
+		}
+	}
+	// Return (global prices have no region code)
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"description": rawData.Product.Attributes["groupDescription"],
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
 func transformPriceDataForS3(rawData model.RawData) model.ProcessedData {
 	// Process by storage class
 	productType := "none"
@@ -360,8 +1260,8 @@ func transformPriceDataForS3(rawData model.RawData) model.ProcessedData {
 	}
 	// Return
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			operation: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			operation: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		Product: map[string]string{
 			"storageClass": strings.ToLower(rawData.Product.Attributes["storageClass"]),
@@ -375,6 +1275,143 @@ func transformPriceDataForS3(rawData model.RawData) model.ProcessedData {
 	}
 }
 
+func transformPriceDataForSecretsManager(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "APIRequest") {
+		productType = "api"
+		serviceType = "request"
+		onDemandKey = "requests"
+	} else if strings.Contains(usageType, "Secret") {
+		productType = "secret"
+		serviceType = "secret"
+		onDemandKey = "monthly"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForSNS(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	endpointType := rawData.Product.Attributes["endpointType"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if rawData.Product.ProductFamily == "API Request" {
+		productType = "request"
+		if strings.Contains(usageType, "FIFO") {
+			serviceType = "fifo"
+		} else {
+			serviceType = "standard"
+		}
+		onDemandKey = "requests"
+	} else if rawData.Product.ProductFamily == "Message Delivery" {
+		productType = "delivery"
+		onDemandKey = "deliveries"
+		if endpointType == "SMS" || strings.Contains(usageType, "SMS") {
+			// SMS prices per destination country (skipped without a country, so the prices of countries do not collide)
+			productType = "sms"
+			serviceType = transformDataForLocationKey(rawData.Product.Attributes["destinationCountry"])
+			if serviceType == "" {
+				productType = "none"
+			}
+		} else if strings.HasPrefix(endpointType, "HTTP") {
+			serviceType = "http"
+		} else if strings.HasPrefix(endpointType, "Email") || strings.Contains(usageType, "SMTP") {
+			serviceType = "email"
+		} else if endpointType == "Amazon SQS" {
+			serviceType = "sqs"
+		} else if endpointType == "AWS Lambda" {
+			serviceType = "lambda"
+		} else if strings.Contains(endpointType, "Push") || strings.Contains(endpointType, "Messaging") || endpointType == "Mobile" {
+			serviceType = "mobilePush"
+		} else {
+			productType = "none"
+		}
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"endpointType": endpointType,
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
+func transformPriceDataForSQS(rawData model.RawData) model.ProcessedData {
+	// Set service type (queue type)
+	var serviceType string
+	if strings.Contains(rawData.Product.Attributes["queueType"], "FIFO") || strings.Contains(rawData.Product.Attributes["usagetype"], "FIFO") {
+		serviceType = "fifo"
+	} else {
+		serviceType = "standard"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			"requests": transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		Product: map[string]string{
+			"queueType": rawData.Product.Attributes["queueType"],
+		},
+		ProductType: "request",
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   rawData.Product.Attributes["usagetype"],
+	}
+}
+
+func transformPriceDataForStepFunctions(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	group := rawData.Product.Attributes["group"]
+	// Set product type and onDemand key
+	productType := "none"
+	var onDemandKey string
+	if strings.Contains(usageType, "Express") || strings.Contains(group, "Express") {
+		productType = "express"
+		if strings.Contains(usageType, "Duration") || strings.Contains(group, "Duration") {
+			onDemandKey = "duration"
+		} else {
+			onDemandKey = "requests"
+		}
+	} else if strings.Contains(usageType, "StateTransition") || strings.Contains(group, "StateTransition") {
+		productType = "standard"
+		onDemandKey = "transitions"
+	}
+	// Return
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: "stateMachine",
+		UsageType:   usageType,
+	}
+}
+
 func transformPriceDataForVPC(rawData model.RawData) model.ProcessedData {
 	// Set service type
 	var operation string
@@ -401,6 +1438,60 @@ func transformPriceDataForVPC(rawData model.RawData) model.ProcessedData {
 		productType = "clientVpn"
 		serviceType = "endPoints"
 		operation = "usage"
+	} else if strings.Contains(rawData.Product.Attributes["usagetype"], "NatGateway") {
+		productType = "natGateway"
+		serviceType = "gateway"
+		// Set operation
+		if strings.Contains(rawData.Product.Attributes["usagetype"], "Bytes") {
+			operation = "processed"
+		} else {
+			operation = "usage"
+		}
+	} else if strings.Contains(rawData.Product.Attributes["usagetype"], "PublicIPv4") {
+		productType = "publicIpv4"
+		operation = "usage"
+		if strings.Contains(rawData.Product.Attributes["usagetype"], "InUseAddress") {
+			serviceType = "inUse"
+		} else if strings.Contains(rawData.Product.Attributes["usagetype"], "IdleAddress") {
+			serviceType = "idle"
+		} else {
+			productType = "none"
+		}
+	} else if strings.Contains(rawData.Product.Attributes["usagetype"], "ElasticIP") {
+		productType = "elasticIp"
+		operation = "usage"
+		if strings.Contains(rawData.Product.Attributes["usagetype"], "IdleAddress") {
+			serviceType = "idle"
+		} else if strings.Contains(rawData.Product.Attributes["usagetype"], "AdditionalAddress") {
+			serviceType = "additional"
+		} else if strings.Contains(rawData.Product.Attributes["usagetype"], "Remap") {
+			serviceType = "remap"
+		} else {
+			productType = "none"
+		}
+	} else if strings.Contains(rawData.Product.Attributes["usagetype"], "TransitGateway") {
+		productType = "transitGateway"
+		// Set attachment type
+		switch rawData.Product.Attributes["operation"] {
+		case "TransitGatewayVPC":
+			serviceType = "vpc"
+		case "TransitGatewayVPN":
+			serviceType = "vpn"
+		case "TransitGatewayDirectConnect":
+			serviceType = "directConnect"
+		case "TransitGatewayPeering":
+			serviceType = "peering"
+		case "TransitGatewayConnect":
+			serviceType = "connect"
+		default:
+			productType = "none"
+		}
+		// Set operation
+		if strings.Contains(rawData.Product.Attributes["usagetype"], "Bytes") {
+			operation = "processed"
+		} else {
+			operation = "usage"
+		}
 	} else if rawData.Product.Attributes["attachmentType"] == "AWS Site-to-Site VPN" {
 		productType = "siteToSiteVpn"
 		serviceType = "gateway"
@@ -415,8 +1506,8 @@ func transformPriceDataForVPC(rawData model.RawData) model.ProcessedData {
 	}
 	// Return
 	return model.ProcessedData{
-		OnDemand: map[string][]map[string]interface{}{
-			operation: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+		OnDemand: map[string][]model.PriceDimension{
+			operation: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 		},
 		ProductType: productType,
 		Region:      rawData.Product.Attributes["regionCode"],
@@ -426,6 +1517,44 @@ func transformPriceDataForVPC(rawData model.RawData) model.ProcessedData {
 	}
 }
 
+func transformPriceDataForWAF(rawData model.RawData) model.ProcessedData {
+	usageType := rawData.Product.Attributes["usagetype"]
+	// Set product type, service type and onDemand key
+	productType := "none"
+	var serviceType string
+	var onDemandKey string
+	if strings.Contains(usageType, "Request") {
+		productType = "request"
+		if strings.Contains(usageType, "Bot") {
+			serviceType = "botControl"
+		} else if strings.Contains(usageType, "Captcha") {
+			serviceType = "captcha"
+		} else {
+			serviceType = "standard"
+		}
+		onDemandKey = "requests"
+	} else if strings.Contains(usageType, "WebACL") {
+		productType = "webAcl"
+		serviceType = "webAcl"
+		onDemandKey = "monthly"
+	} else if strings.Contains(usageType, "Rule") {
+		productType = "rule"
+		serviceType = "rule"
+		onDemandKey = "monthly"
+	}
+	// Return (global web ACLs for CloudFront have no region code)
+	return model.ProcessedData{
+		OnDemand: map[string][]model.PriceDimension{
+			onDemandKey: transformDataForPricePerUnit(rawData.Terms.OnDemand),
+		},
+		ProductType: productType,
+		Region:      rawData.Product.Attributes["regionCode"],
+		Sku:         rawData.Product.Sku,
+		ServiceType: serviceType,
+		UsageType:   usageType,
+	}
+}
+
 // func transformPriceDataForVpcEndpoint(rawData model.RawData) model.ProcessedData {
 // 	reEpType := regexp.MustCompile("^\\S+-VpcEndpoint-")
 // 	reType := regexp.MustCompile("^GWLBE")
@@ -448,8 +1577,8 @@ func transformPriceDataForVPC(rawData model.RawData) model.ProcessedData {
 // 	}
 
 // 	return model.ProcessedData{
-// 		OnDemand: map[string][]map[string]interface{}{
-// 			priceTarget: transformDataForPricePerUnit(rawData.Terms.OnDemand.(map[string]interface{})),
+// 		OnDemand: map[string][]model.PriceDimension{
+// 			priceTarget: transformDataForPricePerUnit(rawData.Terms.OnDemand),
 // 		},
 // 		Product: map[string]string{
 // 			"type":  epType,
@@ -0,0 +1,755 @@
+package process
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	// Model
+	"aws-price-scanner/model"
+)
+
+// New product with attributes and an on-demand price
+func newTestProduct(sku string, productFamily string, attributes map[string]string, price string) model.RawData {
+	var rawData model.RawData
+	rawData.Product.Sku = sku
+	rawData.Product.ProductFamily = productFamily
+	rawData.Product.Attributes = attributes
+	rawData.Terms.OnDemand = map[string]model.RawTerm{
+		sku + ".JRTCKXETXF": {
+			PriceDimensions: map[string]model.RawPriceDimension{
+				sku + ".JRTCKXETXF.6YS6EN2CT7": {
+					BeginRange:   "0",
+					EndRange:     "Inf",
+					PricePerUnit: map[string]string{"USD": price},
+					Unit:         "Hrs",
+				},
+			},
+		},
+	}
+	return rawData
+}
+
+// Add a reserved term (without upfront fee if upfront is empty) to product
+func addTestReservedTerm(rawData *model.RawData, code string, attributes map[string]string, upfront string, recurring string, unit string) {
+	if rawData.Terms.Reserved == nil {
+		rawData.Terms.Reserved = make(map[string]model.RawTerm)
+	}
+	key := rawData.Product.Sku + "." + code
+	term := model.RawTerm{
+		PriceDimensions: map[string]model.RawPriceDimension{
+			key + ".6YS6EN2CT7": {PricePerUnit: map[string]string{"USD": recurring}, Unit: unit},
+		},
+		TermAttributes: attributes,
+	}
+	if upfront != "" {
+		term.PriceDimensions[key+".2TG2D8R56U"] = model.RawPriceDimension{PricePerUnit: map[string]string{"USD": upfront}, Unit: "Quantity"}
+	}
+	rawData.Terms.Reserved[key] = term
+}
+
+// Check product type, service type and the on-demand price key of processed data
+func checkTestTransform(t *testing.T, data model.ProcessedData, productType string, serviceType string, key string) {
+	t.Helper()
+	if data.ProductType != productType || data.ServiceType != serviceType {
+		t.Errorf("productType %q, serviceType %q, want %q, %q", data.ProductType, data.ServiceType, productType, serviceType)
+	}
+	if productType != "none" && len(data.OnDemand[key]) != 1 {
+		t.Errorf("onDemand %v, want a price under %q", data.OnDemand, key)
+	}
+}
+
+func TestTransformPriceDataForRDSInstanceKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		key        string
+	}{
+		{"single-az", map[string]string{"operation": "CreateDBInstance:0002", "deploymentOption": "Single-AZ"}, "CreateDBInstance:0002"},
+		{"multi-az", map[string]string{"operation": "CreateDBInstance:0002", "deploymentOption": "Multi-AZ"}, "CreateDBInstance:0002:Multi-AZ"},
+		{"multi-az readable standbys", map[string]string{"operation": "CreateDBInstance:0002", "deploymentOption": "Multi-AZ (readable standbys)"}, "CreateDBInstance:0002:Multi-AZ (readable standbys)"},
+		{"io-optimized", map[string]string{"operation": "CreateDBInstance:0021", "deploymentOption": "Single-AZ", "usagetype": "InstanceUsageIOOptimized:db.r6g.large"}, "CreateDBInstance:0021:IOOptimized"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.attributes["currentGeneration"] = "Yes"
+			test.attributes["instanceType"] = "db.r6g.large"
+			data := transformPriceDataForRDS(newTestProduct("SKU", "Database Instance", test.attributes, "1"))
+			if data.ProductType != "instance" {
+				t.Fatalf("productType %q, want instance", data.ProductType)
+			}
+			if _, ok := data.OnDemand[test.key]; !ok || len(data.OnDemand) != 1 {
+				t.Errorf("onDemand keys %v, want %q", data.OnDemand, test.key)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForEC2(t *testing.T) {
+	instance := func(tenancy string, capacityStatus string, instanceType string) map[string]string {
+		return map[string]string{
+			"capacitystatus":  capacityStatus,
+			"instanceFamily":  "General purpose",
+			"instanceType":    instanceType,
+			"operatingSystem": "Linux",
+			"operation":       "RunInstances",
+			"preInstalledSw":  "NA",
+			"regionCode":      "us-east-1",
+			"tenancy":         tenancy,
+			"usagetype":       "BoxUsage:" + instanceType,
+			"vcpu":            "2",
+		}
+	}
+	tests := []struct {
+		name          string
+		productFamily string
+		attributes    map[string]string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"shared", "Compute Instance", instance("Shared", "Used", "m5.large"), "instance", "m5.large", "RunInstances"},
+		{"dedicated", "Compute Instance", instance("Dedicated", "Used", "m5.large"), "dedicated", "m5.large", "RunInstances"},
+		{"bare metal", "Compute Instance (bare metal)", instance("Shared", "Used", "m5.metal"), "instance", "m5.metal", "RunInstances"},
+		{"unused capacity reservation", "Compute Instance", instance("Shared", "UnusedCapacityReservation", "m5.large"), "instance", "m5.large", "RunInstances:UnusedCapacityReservation"},
+		{"allocated capacity reservation", "Compute Instance", instance("Dedicated", "AllocatedCapacityReservation", "m5.large"), "dedicated", "m5.large", "RunInstances:AllocatedCapacityReservation"},
+		{"host", "Dedicated Host", map[string]string{"instanceFamily": "m5", "instanceType": "m5", "operation": "RunInstances", "physicalCores": "48", "regionCode": "us-east-1", "tenancy": "Host", "usagetype": "HostUsage:m5"}, "host", "m5", "RunInstances"},
+		{"host tenancy instance", "Compute Instance", instance("Host", "Used", "m5.large"), "none", "m5.large", "RunInstances"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForEC2(newTestProduct("SKU", test.productFamily, test.attributes, "0.096"))
+			if data.ProductType != test.productType || data.ServiceType != test.serviceType || data.Region != "us-east-1" {
+				t.Errorf("productType %q, serviceType %q, region %q, want %q, %q, us-east-1", data.ProductType, data.ServiceType, data.Region, test.productType, test.serviceType)
+			}
+			if prices, ok := data.OnDemand[test.key]; !ok || len(data.OnDemand) != 1 || len(prices) != 1 {
+				t.Fatalf("onDemand %v, want a price under %q", data.OnDemand, test.key)
+			} else if prices[0].Attributes["tenancy"] != test.attributes["tenancy"] {
+				t.Errorf("attributes %v, want tenancy %q", prices[0].Attributes, test.attributes["tenancy"])
+			}
+			if test.productType == "host" && data.Product["physicalCores"] != "48" {
+				t.Errorf("product %v, want physical cores of host", data.Product)
+			}
+		})
+	}
+}
+
+func TestEC2DefinitionRequestsBareMetal(t *testing.T) {
+	def, _ := Lookup(model.AWS_SERVICE_CODE_EC2)
+	families := make([]string, 0)
+	for _, request := range def.requests() {
+		for _, filter := range request.Filters {
+			if filter.Field == "productFamily" {
+				families = append(families, filter.Value)
+			}
+		}
+	}
+	want := []string{"Compute Instance", "Compute Instance (bare metal)", "Dedicated Host"}
+	if !reflect.DeepEqual(families, want) {
+		t.Errorf("product families %v, want %v", families, want)
+	}
+}
+
+func TestTransformPriceDataForSNS(t *testing.T) {
+	delivery := func(endpointType string, usageType string) map[string]string {
+		return map[string]string{"endpointType": endpointType, "group": "SNS-Delivery", "location": "US East (N. Virginia)", "locationType": "AWS Region", "regionCode": "us-east-1", "servicecode": "AmazonSNS", "usagetype": usageType}
+	}
+	sms := delivery("SMS", "DeliveryAttempts-SMS")
+	sms["destinationCountry"] = "United Kingdom"
+
+	tests := []struct {
+		name          string
+		productFamily string
+		attributes    map[string]string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"standard request", "API Request", map[string]string{"regionCode": "us-east-1", "usagetype": "Requests-Tier1"}, "request", "standard", "requests"},
+		{"fifo request", "API Request", map[string]string{"regionCode": "us-east-1", "usagetype": "Requests-FIFO-Tier1"}, "request", "fifo", "requests"},
+		{"http", "Message Delivery", delivery("HTTP", "DeliveryAttempts-HTTP"), "delivery", "http", "deliveries"},
+		{"email", "Message Delivery", delivery("Email/Email-JSON", "DeliveryAttempts-SMTP"), "delivery", "email", "deliveries"},
+		{"mobile push", "Message Delivery", delivery("Mobile Push Notifications", "DeliveryAttempts-APNS"), "delivery", "mobilePush", "deliveries"},
+		{"sms", "Message Delivery", sms, "sms", "united-kingdom", "deliveries"},
+		{"sms without country", "Message Delivery", delivery("SMS", "DeliveryAttempts-SMS"), "none", "", "deliveries"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForSNS(newTestProduct("SKU", test.productFamily, test.attributes, "0.00645"))
+			if data.ProductType != test.productType || data.ServiceType != test.serviceType || data.Region != "us-east-1" {
+				t.Errorf("productType %q, serviceType %q, region %q, want %q, %q, us-east-1", data.ProductType, data.ServiceType, data.Region, test.productType, test.serviceType)
+			}
+			if prices := data.OnDemand[test.key]; len(prices) != 1 || prices[0].Price[0].Amount != "0.00645" {
+				t.Errorf("onDemand %v, want a price under %q", data.OnDemand, test.key)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForDataTransfer(t *testing.T) {
+	route := func(from string, fromCode string, to string, toCode string, transferType string) map[string]string {
+		return map[string]string{"fromLocation": from, "fromLocationType": "AWS Region", "fromRegionCode": fromCode, "servicecode": "AWSDataTransfer", "toLocation": to, "toLocationType": "AWS Region", "toRegionCode": toCode, "transferType": transferType, "usagetype": "USE1-DataTransfer-Out-Bytes"}
+	}
+	internetOut := route("US East (N. Virginia)", "us-east-1", "External", "", "AWS Outbound")
+	internetOut["toLocationType"] = "Other"
+	internetIn := route("External", "", "US East (N. Virginia)", "us-east-1", "AWS Inbound")
+	internetIn["fromLocationType"] = "Other"
+
+	tests := []struct {
+		name        string
+		attributes  map[string]string
+		productType string
+		from        string
+		to          string
+		serviceType string
+	}{
+		{"internet out", internetOut, "dataTransfer", "us-east-1", "internet", "internetOut"},
+		{"internet in", internetIn, "dataTransfer", "internet", "us-east-1", "internetIn"},
+		{"inter region out", route("US East (N. Virginia)", "us-east-1", "EU (Ireland)", "eu-west-1", "InterRegion Outbound"), "dataTransfer", "us-east-1", "eu-west-1", "interRegionOut"},
+		{"inter region in", route("EU (Ireland)", "eu-west-1", "US East (N. Virginia)", "us-east-1", "InterRegion Inbound"), "dataTransfer", "eu-west-1", "us-east-1", "interRegionIn"},
+		{"intra region", route("US East (N. Virginia)", "us-east-1", "US East (N. Virginia)", "us-east-1", "IntraRegion"), "dataTransfer", "us-east-1", "us-east-1", "intraRegion"},
+		{"region code of location name", route("EU (Ireland)", "", "EU (London)", "", "InterRegion Outbound"), "dataTransfer", "eu-west-1", "eu-west-2", "interRegionOut"},
+		{"unknown location", route("US West (Los Angeles)", "", "US West (Oregon)", "us-west-2", "InterRegion Outbound"), "dataTransfer", "us-west-los-angeles", "us-west-2", "interRegionOut"},
+		{"other transfer type", route("US East (N. Virginia)", "us-east-1", "EU (Ireland)", "eu-west-1", "Accelerated InterRegion Outbound"), "dataTransfer", "us-east-1", "eu-west-1", "accelerated-interregion-outbound"},
+		{"no location", route("", "", "EU (Ireland)", "eu-west-1", "InterRegion Inbound"), "none", "", "eu-west-1", "interRegionIn"},
+		{"no transfer type", route("US East (N. Virginia)", "us-east-1", "EU (Ireland)", "eu-west-1", ""), "none", "us-east-1", "eu-west-1", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForDataTransfer(newTestProduct("SKU", "Data Transfer", test.attributes, "0.02"))
+			if data.ProductType != test.productType || data.ServiceType != test.serviceType {
+				t.Errorf("productType %q, serviceType %q, want %q, %q", data.ProductType, data.ServiceType, test.productType, test.serviceType)
+			}
+			if data.Transfer == nil || data.Transfer.From != test.from || data.Transfer.To != test.to {
+				t.Errorf("transfer %+v, want from %q to %q", data.Transfer, test.from, test.to)
+			}
+			if len(data.OnDemand["transfer"]) != 1 || data.Region != "" || data.Edge != "" {
+				t.Errorf("onDemand %v, region %q, edge %q, want a transfer price out of regions and edges", data.OnDemand, data.Region, data.Edge)
+			}
+		})
+	}
+}
+
+func TestDataTransferCatalog(t *testing.T) {
+	products := []model.RawData{
+		newTestProduct("AAA", "Data Transfer", map[string]string{"fromRegionCode": "us-east-1", "toLocation": "External", "transferType": "AWS Outbound"}, "0.09"),
+		newTestProduct("BBB", "Data Transfer", map[string]string{"fromRegionCode": "us-east-1", "toRegionCode": "eu-west-1", "transferType": "InterRegion Outbound"}, "0.02"),
+		newTestProduct("CCC", "Data Transfer", map[string]string{"fromLocation": "External", "toRegionCode": "us-east-1", "transferType": "AWS Inbound"}, "0"),
+	}
+	catalog := model.NewCatalog(model.AWS_SERVICE_CODE_DATATRANSFER)
+	for _, rawData := range products {
+		catalog.Merge(transformPriceDataForDataTransfer(rawData))
+	}
+	content, err := json.Marshal(catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Transfers are keyed by from location, to location and transfer type
+	var result struct {
+		Regions   map[string]interface{}                                `json:"regions"`
+		Transfers map[string]map[string]map[string]struct{ Sku string } `json:"transfers"`
+	}
+	if err := json.Unmarshal(content, &result); err != nil {
+		t.Fatal(err)
+	}
+	skus := make(map[string]string)
+	for from, routes := range result.Transfers {
+		for to, transfer := range routes {
+			for transferType, offer := range transfer {
+				skus[from+" -> "+to+" "+transferType] = offer.Sku
+			}
+		}
+	}
+	expected := map[string]string{
+		"us-east-1 -> internet internetOut":     "AAA",
+		"us-east-1 -> eu-west-1 interRegionOut": "BBB",
+		"internet -> us-east-1 internetIn":      "CCC",
+	}
+	if !reflect.DeepEqual(skus, expected) || len(result.Regions) != 0 {
+		t.Errorf("transfers %v, regions %v, want %v and no region\n%s", skus, result.Regions, expected, content)
+	}
+	if offer := catalog.Transfers["us-east-1"]["internet"]["internetOut"]; offer == nil || offer.OnDemand["transfer"][0].Price[0].Amount != "0.09" {
+		t.Errorf("internet out offer %+v, want price 0.09 under transfer", offer)
+	}
+}
+
+func TestCloudFrontCatalog(t *testing.T) {
+	// Data transfer out is tiered
+	transferOut := newTestProduct("AAA", "Data Transfer", map[string]string{"fromLocation": "United States", "fromLocationType": "AWS Edge Location", "toLocation": "External", "transferType": "CloudFront Outbound", "usagetype": "US-DataTransfer-Out-Bytes"}, "0.085")
+	for _, term := range transferOut.Terms.OnDemand {
+		term.PriceDimensions["AAA.JRTCKXETXF.ABCDEFGHIJ"] = model.RawPriceDimension{BeginRange: "10240", EndRange: "Inf", PricePerUnit: map[string]string{"USD": "0.08"}, Unit: "GB"}
+	}
+	products := []model.RawData{
+		transferOut,
+		newTestProduct("BBB", "Data Transfer", map[string]string{"fromLocation": "United States", "toLocation": "External", "transferType": "CloudFront to Origin", "usagetype": "US-DataTransfer-Out-OBytes"}, "0.02"),
+		newTestProduct("CCC", "Request", map[string]string{"location": "United States", "requestType": "CloudFront-Request-HTTP-Static", "usagetype": "US-Requests-Tier1"}, "0.0000007500"),
+		newTestProduct("DDD", "Request", map[string]string{"location": "United States", "requestType": "CloudFront-Request-HTTPS-Static", "usagetype": "US-Requests-Tier2-HTTPS"}, "0.0000010000"),
+		newTestProduct("EEE", "Request", map[string]string{"location": "United States", "requestType": "CloudFront-Request-HTTP-Proxy", "usagetype": "US-Requests-HTTP-Proxy"}, "0.0000200000"),
+		newTestProduct("FFF", "Request", map[string]string{"location": "United States", "requestType": "CloudFront-Request-OriginShield", "usagetype": "US-Requests-OriginShield"}, "0.0000075000"),
+		newTestProduct("GGG", "Request", map[string]string{"requestType": "CloudFront-Request-HTTP-Static", "usagetype": "Requests-Tier1"}, "0.0000007500"),
+	}
+	catalog := model.NewCatalog(model.AWS_SERVICE_CODE_CLOUDFRONT)
+	for _, rawData := range products {
+		if data := transformPriceDataForCloudFront(rawData); data.ProductType != "none" {
+			catalog.Merge(data)
+		}
+	}
+	if len(catalog.Regions) != 0 || len(catalog.Edges) != 1 {
+		t.Fatalf("regions %v, edges %v, want only the united-states edge", catalog.Regions, catalog.Edges)
+	}
+	edge := catalog.Edges["united-states"]
+
+	tests := []struct {
+		productType string
+		serviceType string
+		key         string
+		sku         string
+		amounts     []model.Decimal
+	}{
+		{"dataTransfer", "toInternet", "transfer", "AAA", []model.Decimal{"0.085", "0.08"}},
+		{"dataTransfer", "toOrigin", "transfer", "BBB", []model.Decimal{"0.02"}},
+		{"request", "http", "requests", "CCC", []model.Decimal{"0.00000075"}},
+		{"request", "https", "requests", "DDD", []model.Decimal{"0.000001"}},
+		// Proxy requests share the offer of http requests (the lowest sku)
+		{"request", "http", "proxy", "CCC", []model.Decimal{"0.00002"}},
+		{"originShield", "request", "requests", "FFF", []model.Decimal{"0.0000075"}},
+	}
+	for _, test := range tests {
+		offer := edge[test.productType][test.serviceType]
+		if offer == nil {
+			t.Errorf("no %s/%s under edge: %v", test.productType, test.serviceType, edge)
+			continue
+		}
+		if offer.Sku != test.sku {
+			t.Errorf("%s/%s sku %s, want %s", test.productType, test.serviceType, offer.Sku, test.sku)
+		}
+		var amounts []model.Decimal
+		for _, price := range offer.OnDemand[test.key] {
+			amounts = append(amounts, price.Price[0].Amount)
+		}
+		if !reflect.DeepEqual(amounts, test.amounts) {
+			t.Errorf("%s/%s %s prices %v, want %v (in tier order)", test.productType, test.serviceType, test.key, amounts, test.amounts)
+		}
+	}
+}
+
+func TestTransformDataForReserved(t *testing.T) {
+	term := func(length string, option string, class string) map[string]string {
+		return map[string]string{"LeaseContractLength": length, "OfferingClass": class, "PurchaseOption": option}
+	}
+	rawData := newTestProduct("SKU", "Compute Instance", nil, "0.096")
+	addTestReservedTerm(&rawData, "4NA7Y494T4", term("3yr", "No Upfront", "standard"), "", "0.0420000000", "Hrs")
+	addTestReservedTerm(&rawData, "38NPMPTW36", term("3yr", "Partial Upfront", "standard"), "1043", "0.0400000000", "Hrs")
+	addTestReservedTerm(&rawData, "6QCMYABX3D", term("1yr", "All Upfront", "standard"), "520", "0.0000000000", "Hrs")
+	addTestReservedTerm(&rawData, "7NE97W5U4E", term("1yr", "No Upfront", "convertible"), "", "0.0710000000", "Hrs")
+	addTestReservedTerm(&rawData, "HU7G6KETJZ", term("1yr", "Partial Upfront", "standard"), "262", "0.0300000000", "Hrs")
+	addTestReservedTerm(&rawData, "4NA7Y494T5", term("1yr", "No Upfront", "standard"), "", "0.0600000000", "Hrs")
+
+	tests := []struct {
+		length    string
+		option    string
+		class     string
+		upfront   model.Decimal
+		recurring model.Decimal
+	}{
+		{"1yr", "All Upfront", "standard", "520", "0"},
+		{"1yr", "No Upfront", "convertible", "", "0.071"},
+		{"1yr", "No Upfront", "standard", "", "0.06"},
+		{"1yr", "Partial Upfront", "standard", "262", "0.03"},
+		{"3yr", "No Upfront", "standard", "", "0.042"},
+		{"3yr", "Partial Upfront", "standard", "1043", "0.04"},
+	}
+	result := transformDataForReserved(rawData.Terms.Reserved)
+	if len(result) != len(tests) {
+		t.Fatalf("reserved %+v, want %d terms", result, len(tests))
+	}
+	// Sorted by lease contract length, purchase option and offering class
+	for i, test := range tests {
+		reserved := result[i]
+		if reserved.LeaseContractLength != test.length || reserved.PurchaseOption != test.option || reserved.OfferingClass != test.class {
+			t.Errorf("term %d: %s %s %s, want %s %s %s", i, reserved.LeaseContractLength, reserved.PurchaseOption, reserved.OfferingClass, test.length, test.option, test.class)
+		}
+		if test.upfront == "" {
+			if reserved.Upfront != nil || reserved.UpfrontFee != nil {
+				t.Errorf("term %d: upfront %v, want no upfront fee", i, reserved.Upfront)
+			}
+		} else if len(reserved.Upfront) != 1 || reserved.Upfront[0].Amount != test.upfront || reserved.UpfrontFee["USD"] == "" {
+			t.Errorf("term %d: upfront %v, want %s", i, reserved.Upfront, test.upfront)
+		}
+		if len(reserved.Recurring) != 1 || reserved.Recurring[0].Amount != test.recurring || reserved.RecurringFee["USD"] == "" || reserved.RecurringUnit != "Hrs" {
+			t.Errorf("term %d: recurring %v per %q, want %s per Hrs", i, reserved.Recurring, reserved.RecurringUnit, test.recurring)
+		}
+	}
+}
+
+func TestTransformPriceDataWithReserved(t *testing.T) {
+	term := map[string]string{"LeaseContractLength": "1yr", "OfferingClass": "standard", "PurchaseOption": "Partial Upfront"}
+	ec2 := newTestProduct("EC2", "Compute Instance", map[string]string{"instanceType": "m5.large", "operatingSystem": "Linux", "operation": "RunInstances", "regionCode": "us-east-1", "tenancy": "Shared"}, "0.096")
+	addTestReservedTerm(&ec2, "HU7G6KETJZ", term, "262", "0.0300000000", "Hrs")
+	rds := newTestProduct("RDS", "Database Instance", map[string]string{"currentGeneration": "Yes", "deploymentOption": "Multi-AZ", "instanceType": "db.m5.large", "operation": "CreateDBInstance:0002", "regionCode": "us-east-1"}, "0.342")
+	addTestReservedTerm(&rds, "HU7G6KETJZ", term, "1000", "0.1140000000", "Hrs")
+	dynamoDB := newTestProduct("DDB", "Provisioned IOPS", map[string]string{"group": "DDB-ReadUnits", "regionCode": "us-east-1", "usagetype": "ReadCapacityUnit-Hrs"}, "0.00013")
+	addTestReservedTerm(&dynamoDB, "MZU6U2429S", map[string]string{"LeaseContractLength": "1yr", "PurchaseOption": "Heavy Utilization"}, "30", "0.0025000000", "ReadCapacityUnit-Hrs")
+
+	tests := []struct {
+		name      string
+		data      model.ProcessedData
+		key       string
+		upfront   model.Decimal
+		unit      string
+		attribute string
+	}{
+		{"ec2", transformPriceDataForEC2(ec2), "RunInstances", "262", "Hrs", "tenancy"},
+		{"rds", transformPriceDataForRDS(rds), "CreateDBInstance:0002:Multi-AZ", "1000", "Hrs", "deploymentOption"},
+		{"dynamodb", transformPriceDataForDynamoDB(dynamoDB), "operation", "30", "ReadCapacityUnit-Hrs", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reserved := test.data.Reserved[test.key]
+			if len(test.data.Reserved) != 1 || len(reserved) != 1 {
+				t.Fatalf("reserved %+v, want a term under %q", test.data.Reserved, test.key)
+			}
+			if len(reserved[0].Upfront) != 1 || reserved[0].Upfront[0].Amount != test.upfront || reserved[0].RecurringUnit != test.unit {
+				t.Errorf("reserved %+v, want upfront %s and recurring per %s", reserved[0], test.upfront, test.unit)
+			}
+			// Reserved prices share the attributes of on-demand prices
+			if test.attribute != "" && reserved[0].Attributes[test.attribute] != test.data.OnDemand[test.key][0].Attributes[test.attribute] {
+				t.Errorf("attributes %v, want %s of on-demand price", reserved[0].Attributes, test.attribute)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForElastiCache(t *testing.T) {
+	node := func(engine string) map[string]string {
+		return map[string]string{"cacheEngine": engine, "currentGeneration": "Yes", "instanceFamily": "Memory optimized", "instanceType": "cache.r6g.large", "memory": "13.07 GiB", "regionCode": "us-east-1", "usagetype": "NodeUsage:cache.r6g.large", "vcpu": "2"}
+	}
+	tests := []struct {
+		name          string
+		productFamily string
+		attributes    map[string]string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"redis node", "Cache Instance", node("Redis"), "node", "cache.r6g.large", "redis"},
+		{"memcached node", "Cache Instance", node("Memcached"), "node", "cache.r6g.large", "memcached"},
+		{"valkey node", "Cache Instance", node("Valkey"), "node", "cache.r6g.large", "valkey"},
+		{"node without engine", "Cache Instance", node(""), "none", "", ""},
+		{"serverless ecpu", "ElastiCache Serverless", map[string]string{"cacheEngine": "Valkey", "regionCode": "us-east-1", "usagetype": "USE1-ElastiCache:ServerlessValkey-ECPU"}, "serverless", "valkey", "ecpu"},
+		{"serverless data storage", "ElastiCache Serverless", map[string]string{"cacheEngine": "Redis", "regionCode": "us-east-1", "usagetype": "USE1-ElastiCache:ServerlessRedis-CachedData"}, "serverless", "redis", "dataStorage"},
+		{"serverless without engine", "ElastiCache Serverless", map[string]string{"regionCode": "us-east-1", "usagetype": "USE1-ElastiCache:Serverless-ECPU"}, "none", "", "ecpu"},
+		{"backup storage", "Storage Snapshot", map[string]string{"regionCode": "us-east-1", "usagetype": "USE1-BackupUsage"}, "none", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForElastiCache(newTestProduct("SKU", test.productFamily, test.attributes, "0.206"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+			if test.productType == "node" && (data.Product["vcpu"] != "2" || data.OnDemand[test.key][0].Attributes["cacheEngine"] != test.attributes["cacheEngine"]) {
+				t.Errorf("product %v, attributes %v, want instance product and cache engine", data.Product, data.OnDemand[test.key][0].Attributes)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForRedshift(t *testing.T) {
+	tests := []struct {
+		name          string
+		productFamily string
+		usageType     string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"node", "Compute Instance", "Node:ra3.xlplus", "node", "ra3.xlplus", "usage"},
+		{"managed storage", "Redshift Managed Storage", "RMS:ra3.xlplus", "storage", "managed", "storage"},
+		{"serverless", "Redshift Serverless", "USE1-RedshiftServerless:Usage", "serverless", "rpu", "usage"},
+		{"spectrum", "Redshift Data Scan", "USE1-Spectrum:TB-Scanned", "spectrum", "scan", "scanned"},
+		{"concurrency scaling", "Redshift Concurrency Scaling", "CS:ra3.xlplus", "none", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rawData := newTestProduct("SKU", test.productFamily, map[string]string{"instanceType": "ra3.xlplus", "regionCode": "us-east-1", "usagetype": test.usageType, "vcpu": "4"}, "1.086")
+			addTestReservedTerm(&rawData, "HU7G6KETJZ", map[string]string{"LeaseContractLength": "1yr", "PurchaseOption": "No Upfront"}, "", "0.7440000000", "Hrs")
+			data := transformPriceDataForRedshift(rawData)
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+			if test.productType == "node" && (data.Product["vcpu"] != "4" || len(data.Reserved["usage"]) != 1) {
+				t.Errorf("product %v, reserved %v, want instance product and reserved nodes", data.Product, data.Reserved)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForEKS(t *testing.T) {
+	tests := []struct {
+		name            string
+		usageType       string
+		cpuArchitecture string
+		productType     string
+		serviceType     string
+		key             string
+	}{
+		{"cluster", "USE1-AmazonEKS-Hours:perCluster", "", "cluster", "standard", "usage"},
+		{"extended support", "USE1-AmazonEKS-Hours:extendedSupport", "", "cluster", "extendedSupport", "usage"},
+		{"anywhere", "USE1-AmazonEKSAnywhere-Subscription-1yr", "", "anywhere", "subscription", "subscription"},
+		{"fargate cpu", "USE1-Fargate-vCPU-Hours:perCPU", "x86", "fargate", "cpu", "linux"},
+		{"fargate memory", "USE1-Fargate-GB-Hours", "x86", "fargate", "memory", "linux"},
+		// Ephemeral storage is priced per GB as well
+		{"fargate ephemeral storage", "USE1-Fargate-EphemeralStorage-GB-Hours", "x86", "fargate", "storage", "linux"},
+		{"fargate arm cpu", "USE1-Fargate-ARM-vCPU-Hours:perCPU", "ARM", "fargate", "cpu", "linux-arm"},
+		{"fargate other", "USE1-Fargate-Windows-OS-Hours", "x86", "none", "", "linux"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForEKS(newTestProduct("SKU", "Compute", map[string]string{"cpuArchitecture": test.cpuArchitecture, "regionCode": "us-east-1", "usagetype": test.usageType}, "0.1"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+		})
+	}
+}
+
+func TestTransformPriceDataForApiGateway(t *testing.T) {
+	tests := []struct {
+		name          string
+		productFamily string
+		attributes    map[string]string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"rest", "API Calls", map[string]string{"usagetype": "USE1-ApiGatewayRequest"}, "rest", "api", "requests"},
+		{"http", "API Calls", map[string]string{"usagetype": "USE1-ApiGatewayHttpRequest"}, "http", "api", "requests"},
+		{"websocket messages", "WebSocket", map[string]string{"usagetype": "USE1-ApiGatewayMessage"}, "websocket", "api", "messages"},
+		{"websocket connection minutes", "WebSocket", map[string]string{"usagetype": "USE1-ApiGatewayMinute"}, "websocket", "api", "connectionMinutes"},
+		{"cache", "Amazon API Gateway Cache", map[string]string{"cacheMemorySizeGb": "0.5", "usagetype": "USE1-CacheUsage:0.5GB"}, "cache", "0.5GB", "usage"},
+		{"cache without size", "Amazon API Gateway Cache", map[string]string{"usagetype": "USE1-CacheUsage"}, "none", "GB", "usage"},
+		{"data transfer", "Data Transfer", map[string]string{"usagetype": "USE1-DataTransfer-Out-Bytes"}, "none", "api", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.attributes["regionCode"] = "us-east-1"
+			data := transformPriceDataForApiGateway(newTestProduct("SKU", test.productFamily, test.attributes, "0.0000035"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+		})
+	}
+}
+
+func TestTransformPriceDataForStepFunctions(t *testing.T) {
+	tests := []struct {
+		name        string
+		usageType   string
+		group       string
+		productType string
+		key         string
+	}{
+		{"standard", "USE1-StateTransition", "SFN-StateTransitions", "standard", "transitions"},
+		{"express requests", "USE1-StepFunctions-Express-Requests", "SFN-ExpressRequests", "express", "requests"},
+		{"express duration", "USE1-StepFunctions-Express-Duration-GB-Second", "SFN-ExpressDuration", "express", "duration"},
+		{"other", "USE1-StepFunctions-Activity", "", "none", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForStepFunctions(newTestProduct("SKU", "AWS Step Functions", map[string]string{"group": test.group, "regionCode": "us-east-1", "usagetype": test.usageType}, "0.000025"))
+			checkTestTransform(t, data, test.productType, "stateMachine", test.key)
+		})
+	}
+}
+
+func TestTransformPriceDataForCloudWatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		usageType   string
+		productType string
+		serviceType string
+		key         string
+	}{
+		{"custom metrics", "CW:MetricMonitorUsage", "metric", "custom", "metrics"},
+		{"api requests", "USE1-CW:Requests", "api", "standard", "requests"},
+		{"get metric data", "USE1-CW:GMD-Metrics", "api", "getMetricData", "requests"},
+		{"logs ingestion", "USE1-DataProcessing-Bytes", "logs", "ingestion", "standard"},
+		{"logs ingestion infrequent access", "USE1-DataProcessingIA-Bytes", "logs", "ingestion", "infrequentAccess"},
+		{"logs storage", "USE1-TimedStorage-ByteHrs", "logs", "storage", "storage"},
+		{"logs insights", "USE1-DataScanned-Bytes", "logs", "insights", "scanned"},
+		{"standard alarm", "CW:AlarmMonitorUsage", "alarm", "standard", "alarms"},
+		{"high resolution alarm", "CW:HighResAlarmMonitorUsage", "alarm", "highResolution", "alarms"},
+		{"composite alarm", "USE1-CW:CompositeAlarmMonitorUsage", "alarm", "composite", "alarms"},
+		{"dashboard", "DashboardsUsageHour", "dashboard", "dashboard", "dashboards"},
+		{"metric streams", "USE1-CW:MetricStreamUsage", "none", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForCloudWatch(newTestProduct("SKU", "Metric", map[string]string{"regionCode": "us-east-1", "usagetype": test.usageType}, "0.5"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+		})
+	}
+}
+
+func TestTransformPriceDataForRoute53(t *testing.T) {
+	tests := []struct {
+		name          string
+		productFamily string
+		usageType     string
+		regionCode    string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"hosted zone", "DNS Zone", "HostedZone", "", "hostedZone", "standard", "zones"},
+		{"standard queries", "DNS Query", "DNS-Queries", "", "query", "standard", "queries"},
+		{"latency queries", "DNS Query", "LBR-Queries", "", "query", "latency", "queries"},
+		{"geo queries", "DNS Query", "Geo-Queries", "", "query", "geo", "queries"},
+		{"ip based queries", "DNS Query", "IPBR-Queries", "", "query", "ipBased", "queries"},
+		{"aws health check", "DNS Health Check", "Health-Check-AWS", "", "healthCheck", "aws", "basic"},
+		{"non aws health check https", "DNS Health Check", "Health-Check-Option-NonAWS-HTTPS", "", "healthCheck", "nonAws", "https"},
+		{"aws health check string matching", "DNS Health Check", "Health-Check-Option-AWS-String-Match", "", "healthCheck", "aws", "stringMatching"},
+		{"health check fast interval", "DNS Health Check", "Health-Check-Option-AWS-Fast-Interval", "", "healthCheck", "aws", "fastInterval"},
+		{"health check latency measurement", "DNS Health Check", "Health-Check-Option-AWS-Latency-Measurement", "", "healthCheck", "aws", "latencyMeasurement"},
+		{"resolver endpoint", "DNS Query", "USE1-ResolverNetworkInterface", "us-east-1", "resolver", "endpoint", "usage"},
+		{"resolver queries", "DNS Query", "USE1-Resolver-Queries", "us-east-1", "resolver", "query", "queries"},
+		// Firewall queries are not resolver queries
+		{"resolver dns firewall", "DNS Query", "USE1-DNS-Firewall-Queries", "us-east-1", "resolver", "firewall", "queries"},
+		{"traffic flow", "DNS Policy", "TrafficFlow-Policy-Record", "", "none", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForRoute53(newTestProduct("SKU", test.productFamily, map[string]string{"regionCode": test.regionCode, "usagetype": test.usageType}, "0.5"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+			if test.productType == "none" {
+				return
+			}
+			// Prices other than Resolver are merged into the global region
+			region := test.regionCode
+			if region == "" {
+				region = model.REGION_GLOBAL
+			}
+			catalog := model.NewCatalog(model.AWS_SERVICE_CODE_ROUTE53)
+			catalog.Merge(data)
+			if catalog.Regions[region][test.productType][test.serviceType] == nil {
+				t.Errorf("regions %v, want %s/%s under %s", catalog.Regions, test.productType, test.serviceType, region)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForOpenSearch(t *testing.T) {
+	tests := []struct {
+		name          string
+		productFamily string
+		attributes    map[string]string
+		productType   string
+		serviceType   string
+		key           string
+	}{
+		{"data node", "Amazon OpenSearch Service Instance", map[string]string{"instanceType": "r6g.large.search", "usagetype": "ESInstance:r6g.large", "vcpu": "2"}, "instance", "r6g.large.search", "usage"},
+		{"ultrawarm node", "Amazon OpenSearch Service Instance", map[string]string{"instanceType": "ultrawarm1.medium.search", "usagetype": "ESInstance:ultrawarm1.medium", "vcpu": "2"}, "ultraWarm", "ultrawarm1.medium.search", "usage"},
+		{"ebs storage", "Amazon OpenSearch Service Volume", map[string]string{"storageMedia": "GP3", "usagetype": "ES:GP3-Storage"}, "storage", "gp3", "storage"},
+		{"ebs iops", "Amazon OpenSearch Service Volume", map[string]string{"storageMedia": "GP3", "usagetype": "ES:GP3-PIOPS"}, "storage", "gp3", "iops"},
+		{"ebs throughput", "Amazon OpenSearch Service Volume", map[string]string{"storageMedia": "GP3", "usagetype": "ES:GP3-Throughput"}, "storage", "gp3", "throughput"},
+		{"ultrawarm storage", "Amazon OpenSearch Service Volume", map[string]string{"storageMedia": "Managed-Storage", "usagetype": "ES:Managed-Storage"}, "ultraWarm", "managedStorage", "storage"},
+		{"cold storage", "Amazon OpenSearch Service Volume", map[string]string{"storageMedia": "Cold-Storage", "usagetype": "ES:ColdStorage-ByteHrs"}, "coldStorage", "managed", "storage"},
+		{"serverless indexing", "Amazon OpenSearch Serverless", map[string]string{"usagetype": "USE1-IndexingOCU"}, "serverless", "indexing", "ocu"},
+		{"serverless search", "Amazon OpenSearch Serverless", map[string]string{"usagetype": "USE1-SearchOCU"}, "serverless", "search", "ocu"},
+		{"instance without type", "Amazon OpenSearch Service Instance", map[string]string{"usagetype": "ESInstance"}, "none", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.attributes["regionCode"] = "us-east-1"
+			data := transformPriceDataForOpenSearch(newTestProduct("SKU", test.productFamily, test.attributes, "0.167"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+			if test.key == "usage" && data.Product["vcpu"] != "2" {
+				t.Errorf("product %v, want instance product", data.Product)
+			}
+		})
+	}
+}
+
+func TestTransformPriceDataForKinesis(t *testing.T) {
+	tests := []struct {
+		name        string
+		usageType   string
+		productType string
+		serviceType string
+	}{
+		{"shard hour", "USE1-Storage-ShardHour", "provisioned", "shard"},
+		{"put payload units", "USE1-PutRequestPayloadUnits", "provisioned", "putPayloadUnit"},
+		{"extended retention", "USE1-Extended-ShardHour", "provisioned", "extendedRetention"},
+		{"long term retention", "USE1-LongTerm-Storage-ByteHrs", "provisioned", "longTermRetention"},
+		{"on-demand stream hour", "USE1-OnDemand-StreamHour", "onDemand", "stream"},
+		{"on-demand data in", "USE1-OnDemand-BilledIncomingBytes", "onDemand", "dataIn"},
+		{"on-demand data out", "USE1-OnDemand-BilledOutgoingBytes", "onDemand", "dataOut"},
+		{"on-demand extended retention", "USE1-OnDemand-Extended-ByteHrs", "onDemand", "extendedRetention"},
+		// Enhanced fan-out overrides the capacity mode
+		{"enhanced fan-out consumer", "USE1-EnhancedFanoutConsumer-ShardHour", "enhancedFanOut", "consumerShard"},
+		{"enhanced fan-out data retrieval", "USE1-EnhancedFanoutDataRetrieval-Bytes", "enhancedFanOut", "dataRetrieval"},
+		{"on-demand enhanced fan-out", "USE1-OnDemand-EnhancedFanout-BilledOutgoingBytes", "enhancedFanOut", "dataRetrieval"},
+		{"other", "USE1-DataTransfer-Out-Bytes", "none", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForKinesis(newTestProduct("SKU", "Kinesis Streams", map[string]string{"regionCode": "us-east-1", "usagetype": test.usageType}, "0.015"))
+			checkTestTransform(t, data, test.productType, test.serviceType, "usage")
+		})
+	}
+}
+
+func TestTransformPriceDataForFirehose(t *testing.T) {
+	tests := []struct {
+		name        string
+		usageType   string
+		productType string
+		serviceType string
+	}{
+		{"direct put", "USE1-BilledBytes", "ingestion", "directPut"},
+		{"kinesis stream source", "USE1-KinesisStream-BilledBytes", "ingestion", "kinesisStream"},
+		{"vended logs", "USE1-VendedLogs-BilledBytes", "ingestion", "vendedLogs"},
+		{"format conversion", "USE1-FormatConversion-Bytes", "formatConversion", "conversion"},
+		{"dynamic partitioning", "USE1-DynamicPartitioning-Bytes", "dynamicPartitioning", "partitioning"},
+		{"vpc delivery", "USE1-VpcDelivery-Bytes", "vpcDelivery", "delivery"},
+		{"other", "USE1-DataTransfer-Out-Bytes", "none", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := transformPriceDataForFirehose(newTestProduct("SKU", "Kinesis Firehose", map[string]string{"regionCode": "us-east-1", "usagetype": test.usageType}, "0.029"))
+			checkTestTransform(t, data, test.productType, test.serviceType, "usage")
+		})
+	}
+}
+
+func TestTransformPriceDataForSecurityServices(t *testing.T) {
+	tests := []struct {
+		name        string
+		transform   func(rawData model.RawData) model.ProcessedData
+		usageType   string
+		productType string
+		serviceType string
+		key         string
+	}{
+		{"ecr storage", transformPriceDataForECR, "USE1-TimedStorage-ByteHrs", "storage", "repository", "storage"},
+		{"ecr replication", transformPriceDataForECR, "USE1-USW2-Replication-Bytes", "replication", "crossRegion", "transfer"},
+		{"ecr data transfer", transformPriceDataForECR, "USE1-DataTransfer-Out-Bytes", "none", "", ""},
+		{"kms key", transformPriceDataForKMS, "USE1-KMS-Keys", "key", "customerManaged", "monthly"},
+		{"kms custom key store", transformPriceDataForKMS, "USE1-KMS-CustomKeyStore", "key", "customKeyStore", "monthly"},
+		{"kms symmetric requests", transformPriceDataForKMS, "USE1-KMS-Requests", "request", "symmetric", "requests"},
+		{"kms asymmetric requests", transformPriceDataForKMS, "USE1-KMS-Requests-Asymmetric", "request", "asymmetric", "requests"},
+		{"kms rsa requests", transformPriceDataForKMS, "USE1-KMS-Requests-RSA-2048", "request", "asymmetric", "requests"},
+		// Data key pairs are generated with RSA or ECC keys
+		{"kms generate data key pair", transformPriceDataForKMS, "USE1-KMS-Requests-GenerateDataKeyPair-RSA", "request", "generateDataKeyPair", "requests"},
+		{"secret", transformPriceDataForSecretsManager, "USE1-AWSSecretsManager-Secrets", "secret", "secret", "monthly"},
+		// API requests are named after the service as well
+		{"secrets manager api request", transformPriceDataForSecretsManager, "USE1-AWSSecretsManager-APIRequest", "api", "request", "requests"},
+		{"waf web acl", transformPriceDataForWAF, "USE1-WebACL", "webAcl", "webAcl", "monthly"},
+		{"waf rule", transformPriceDataForWAF, "USE1-Rule", "rule", "rule", "monthly"},
+		{"waf requests", transformPriceDataForWAF, "USE1-Request", "request", "standard", "requests"},
+		{"waf bot control requests", transformPriceDataForWAF, "USE1-BotControl-Request", "request", "botControl", "requests"},
+		{"waf captcha requests", transformPriceDataForWAF, "USE1-Captcha-Request", "request", "captcha", "requests"},
+		// Requests of web ACLs and rule groups are requests
+		{"waf web acl requests", transformPriceDataForWAF, "USE1-WebACL-Request", "request", "standard", "requests"},
+		{"waf rule group requests", transformPriceDataForWAF, "USE1-RuleGroup-Request", "request", "standard", "requests"},
+		{"waf other", transformPriceDataForWAF, "USE1-ShieldProtected-Bytes", "none", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := test.transform(newTestProduct("SKU", "Security", map[string]string{"regionCode": "us-east-1", "usagetype": test.usageType}, "1"))
+			checkTestTransform(t, data, test.productType, test.serviceType, test.key)
+		})
+	}
+}
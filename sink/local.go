@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local stores output artifacts as files in a local directory
+type Local struct {
+	Directory string
+}
+
+/*
+ * New sink to store output in local directory (create directory if not exists)
+ * @param			directory {string} directory path
+ * @response	{*Local} sink object
+ * @response	{error} error object (contain nil)
+ */
+func NewLocal(directory string) (*Local, error) {
+	if directory == "" {
+		return nil, errors.New("Setting the output directory is mandatory")
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	return &Local{Directory: directory}, nil
+}
+
+/*
+ * [Method] Write artifact to file (write temp file and rename it, so readers never see a partial file)
+ * @param			ctx {context.Context} context
+ * @param			artifact {Artifact} output artifact
+ * @response	{string} file path of output
+ * @response	{error} error object (contain nil)
+ */
+func (l *Local) Write(ctx context.Context, artifact Artifact) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	filename := filepath.Join(l.Directory, filepath.FromSlash(artifact.Name))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return "", err
+	}
+	// Create temp file in same directory (rename is atomic only within a file system)
+	file, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpName := file.Name()
+	// Write data
+	if _, err := file.Write(artifact.Body); err != nil {
+		file.Close()
+		os.Remove(tmpName)
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpName)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpName)
+		return "", err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return "", err
+	}
+	// Replace output
+	if err := os.Rename(tmpName, filename); err != nil {
+		os.Remove(tmpName)
+		return "", err
+	}
+	return filename, nil
+}
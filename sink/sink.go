@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+const CONTENT_TYPE_JSON = "application/json"
+
+// Artifact is a named output (e.g. "AmazonEC2.json") with its content type and metadata
+type Artifact struct {
+	Name        string
+	ContentType string
+	Metadata    map[string]string
+	Body        []byte
+}
+
+// Sink is a destination for output artifacts (AWS S3, local directory, ...)
+type Sink interface {
+	// Write stores the artifact and returns the location where it was stored
+	Write(ctx context.Context, artifact Artifact) (string, error)
+}
+
+/*
+ * Create a JSON artifact
+ * @param			name {string} artifact name
+ * @param			data {interface{}} data to encode
+ * @param			metadata {map[string]string} artifact metadata (contain nil)
+ * @response	{Artifact} artifact
+ * @response	{error} error object (contain nil)
+ */
+func NewJSONArtifact(name string, data interface{}, metadata map[string]string) (Artifact, error) {
+	// Transform to byte
+	body, err := json.Marshal(data)
+	if err != nil {
+		return Artifact{}, err
+	}
+	return Artifact{
+		Name:        name,
+		ContentType: CONTENT_TYPE_JSON,
+		Metadata:    metadata,
+		Body:        body,
+	}, nil
+}
+
+// Multi writes every artifact to all of its sinks in order
+type Multi []Sink
+
+/*
+ * [Method] Write artifact to all sinks (stop at first error)
+ * @param			ctx {context.Context} context
+ * @param			artifact {Artifact} artifact
+ * @response	{string} locations joined with ", "
+ * @response	{error} error object (contain nil)
+ */
+func (m Multi) Write(ctx context.Context, artifact Artifact) (string, error) {
+	locations := make([]string, 0, len(m))
+	for _, s := range m {
+		location, err := s.Write(ctx, artifact)
+		if err != nil {
+			return "", err
+		}
+		locations = append(locations, location)
+	}
+	return strings.Join(locations, ", "), nil
+}